@@ -0,0 +1,134 @@
+package main
+
+import (
+	"GoZip/zip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+)
+
+// newFlagSet returns a flag set for a command that prints its usage line
+// and flags to stderr.
+func newFlagSet(name, usageLine string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: gozip %s %s\n", name, usageLine)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags that may appear before, between or after the
+// positional arguments and returns the positional arguments. Everything
+// after "--" is positional. It returns the exit code to use if parsing
+// stopped, which is exitOK for -h.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, int, bool) {
+	var rest []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, rest = args[:i], args[i+1:]
+	}
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, exitOK, false
+			}
+			return nil, exitUsage, false
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	return append(positional, rest...), exitOK, true
+}
+
+// fail reports err for command name on stderr and returns exitFailure.
+// Errors joined with errors.Join are reported one per line.
+func fail(name string, err error) int {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			fmt.Fprintf(os.Stderr, "gozip %s: %v\n", name, e)
+		}
+		return exitFailure
+	}
+	fmt.Fprintf(os.Stderr, "gozip %s: %v\n", name, err)
+	return exitFailure
+}
+
+// openArchive opens the archive at path and returns a function to close it.
+func openArchive(path string) (*zip.Reader, func(), error) {
+	return openArchiveWithOptions(path, zip.ReaderOptions{})
+}
+
+// openArchiveWithOptions is like openArchive but reads the archive as
+// directed by opts.
+func openArchiveWithOptions(path string, opts zip.ReaderOptions) (*zip.Reader, func(), error) {
+	rc, err := zip.OpenReaderWithOptions(path, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rc.Reader, func() { rc.Close() }, nil
+}
+
+// interruptContext returns a context that is cancelled on Ctrl-C, so that
+// commands can stop and remove their partial output before exiting.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// writeArchive creates the archive at path by calling fill with a writer
+// on a temporary file in the same directory, and renames it into place
+// only once the archive is complete. The existing archive at path, if any,
+// stays readable until then, so it can be used as a source. If ctx is done
+// first, the temporary file is removed and path is left untouched.
+//
+// When src is the archive being rewritten, its prepended data, such as a
+// self-extractor stub, is copied ahead of the new entries with the same
+// kind of offsets. The file mode of an existing archive is kept, so that
+// self-extractors stay executable.
+func writeArchive(ctx context.Context, path string, src *zip.Reader, fill func(zw *zip.ZipWriter) error) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".gozip-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	zw := zip.NewZipWriter(tmp)
+	if src != nil && src.PrefixLength() > 0 {
+		if err := zw.SetPrefix(src.Prefix(), src.OffsetMode()); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := fill(zw); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.CloseContext(ctx); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
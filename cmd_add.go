@@ -0,0 +1,89 @@
+package main
+
+import (
+	"GoZip/zip"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func runAdd(args []string) int {
+	fs := newFlagSet("add", "[flags] archive.zip path...")
+	store := fs.Bool("store", false, "store files without compression")
+	quiet := fs.Bool("q", false, "do not list the files added")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	method := zip.Deflate
+	if *store {
+		method = zip.Store
+	}
+
+	archive, paths := positional[0], positional[1:]
+	r, closeArchive, err := openArchive(archive)
+	if err != nil {
+		return fail("add", err)
+	}
+	defer closeArchive()
+
+	// Entries with the same name as a new file are replaced
+	replaced := make(map[string]bool)
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			name := zip.EntryName(p)
+			if d.IsDir() {
+				name += "/"
+			}
+			replaced[name] = true
+			return nil
+		})
+		if err != nil {
+			return fail("add", err)
+		}
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	var added []string
+	bar := newProgressBar()
+	err = writeArchive(ctx, archive, r, func(zw *zip.ZipWriter) error {
+		for _, entry := range r.Files {
+			if replaced[entry.Filename] {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := zw.Copy(r, entry); err != nil {
+				return err
+			}
+		}
+
+		if bar != nil {
+			zw.SetObserver(bar)
+		}
+		added, err = zw.AddPathsContext(ctx, paths, method)
+		return err
+	})
+	if bar != nil {
+		bar.Finish()
+	}
+	if !*quiet {
+		for _, name := range added {
+			fmt.Printf("  adding: %s\n", name)
+		}
+	}
+	if err != nil {
+		return fail("add", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"GoZip/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+func runCarve(args []string) int {
+	fs := newFlagSet("carve", "[-v] [--extract dir] file")
+	verbose := fs.Bool("v", false, "list the entries of each archive found")
+	extractDir := fs.String("extract", "", "write each archive found to `dir` as carved-<offset>.zip")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return fail("carve", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fail("carve", err)
+	}
+
+	result, err := zip.Carve(file, stat.Size())
+	if err != nil {
+		return fail("carve", err)
+	}
+
+	if *extractDir != "" {
+		if err := os.MkdirAll(*extractDir, 0755); err != nil {
+			return fail("carve", err)
+		}
+	}
+
+	for _, archive := range result.Archives {
+		fmt.Printf("%08x-%08x  %10d bytes  %d entries\n",
+			archive.Start, archive.End, archive.End-archive.Start, len(archive.Files))
+		if *verbose {
+			for _, entry := range archive.Files {
+				fmt.Printf("    %10d  %s\n", entry.UncompressedSize, entry.Filename)
+			}
+		}
+
+		if *extractDir != "" {
+			path := filepath.Join(*extractDir, fmt.Sprintf("carved-%08x.zip", archive.Start))
+			if err := writeSection(path, archive.Section(file)); err != nil {
+				return fail("carve", err)
+			}
+			fmt.Printf("    written to %s\n", path)
+		}
+	}
+
+	if len(result.OrphanHeaders) > 0 {
+		fmt.Printf("%d local file headers do not belong to any archive found:\n", len(result.OrphanHeaders))
+		for _, offset := range result.OrphanHeaders {
+			fmt.Printf("    %08x\n", offset)
+		}
+	}
+	if len(result.Archives) == 0 {
+		fmt.Fprintln(os.Stderr, "gozip carve: no archives found")
+		return exitFailure
+	}
+	return exitOK
+}
+
+func writeSection(path string, section io.Reader) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, section); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"GoZip/zip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// writeErrorWriter remembers write errors so they can be told apart from
+// errors reading the entry.
+type writeErrorWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *writeErrorWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// runCat implements "gozip cat", which streams entries to stdout in the
+// order given. All names are looked up before anything is written. It
+// exits with exitMissing if an entry does not exist and exitCorrupt if an
+// entry cannot be decompressed or fails its CRC-32 check.
+func runCat(args []string) int {
+	fs := newFlagSet("cat", "archive.zip name...")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	archive, names := positional[0], positional[1:]
+	r, closeArchive, err := openArchive(archive)
+	if err != nil {
+		return fail("cat", err)
+	}
+	defer closeArchive()
+
+	entries := make([]*zip.CentralDirectoryHeader, 0, len(names))
+	for _, name := range names {
+		entry := r.Find(name)
+		if entry == nil {
+			fail("cat", fmt.Errorf("no entry named %q in %s", name, archive))
+			return exitMissing
+		}
+		entries = append(entries, entry)
+	}
+
+	stdout := &writeErrorWriter{w: os.Stdout}
+	for _, entry := range entries {
+		rc, err := r.Open(entry)
+		if err != nil {
+			fail("cat", err)
+			return exitCorrupt
+		}
+
+		_, err = io.Copy(stdout, rc)
+		rc.Close()
+		if stdout.err != nil {
+			return fail("cat", stdout.err)
+		}
+		if err != nil {
+			fail("cat", fmt.Errorf("%s: %w", entry.Filename, err))
+			return exitCorrupt
+		}
+	}
+	return exitOK
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"GoZip/zip"
+	"fmt"
+	"os"
+)
+
+func runCreate(args []string) int {
+	fs := newFlagSet("create", "[flags] archive.zip path...")
+	store := fs.Bool("store", false, "store files without compression")
+	reproducible := fs.Bool("reproducible", false, "produce identical bytes for identical inputs (honors SOURCE_DATE_EPOCH)")
+	prefix := fs.String("prefix", "", "prepend the contents of `file`, such as a self-extractor stub")
+	relative := fs.Bool("relative-offsets", false, "record offsets relative to the zip data instead of the start of the file")
+	ntfsTimes := fs.Bool("ntfs-times", false, "record times to 100ns in an NTFS extra field for Windows tools")
+	quiet := fs.Bool("q", false, "do not list the files added")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	method := zip.Deflate
+	if *store {
+		method = zip.Store
+	}
+
+	archive, paths := positional[0], positional[1:]
+	ctx, stop := interruptContext()
+	defer stop()
+	var added []string
+	bar := newProgressBar()
+	err := writeArchive(ctx, archive, nil, func(zw *zip.ZipWriter) error {
+		if *prefix != "" {
+			stub, err := os.Open(*prefix)
+			if err != nil {
+				return err
+			}
+			defer stub.Close()
+
+			mode := zip.AbsoluteOffsets
+			if *relative {
+				mode = zip.RelativeOffsets
+			}
+			if err := zw.SetPrefix(stub, mode); err != nil {
+				return err
+			}
+		}
+		if *reproducible {
+			if err := zw.SetReproducible(); err != nil {
+				return err
+			}
+		}
+
+		zw.SetNTFSTimes(*ntfsTimes)
+		if bar != nil {
+			zw.SetObserver(bar)
+		}
+		var err error
+		added, err = zw.AddPathsContext(ctx, paths, method)
+		return err
+	})
+	if bar != nil {
+		bar.Finish()
+	}
+	if !*quiet {
+		for _, name := range added {
+			fmt.Printf("  adding: %s\n", name)
+		}
+	}
+	if err != nil {
+		return fail("create", err)
+	}
+	return exitOK
+}
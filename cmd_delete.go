@@ -0,0 +1,73 @@
+package main
+
+import (
+	"GoZip/zip"
+	"fmt"
+	"strings"
+)
+
+func runDelete(args []string) int {
+	fs := newFlagSet("delete", "[-r] archive.zip name...")
+	recursive := fs.Bool("r", false, "also delete the contents of named directories")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	archive, names := positional[0], positional[1:]
+	r, closeArchive, err := openArchive(archive)
+	if err != nil {
+		return fail("delete", err)
+	}
+	defer closeArchive()
+
+	matched := make(map[string]bool)
+	deleted := func(entry *zip.CentralDirectoryHeader) bool {
+		for _, name := range names {
+			if entry.Filename == name {
+				matched[name] = true
+				return true
+			}
+			dir := strings.TrimSuffix(name, "/") + "/"
+			if *recursive && strings.HasPrefix(entry.Filename, dir) {
+				matched[name] = true
+				return true
+			}
+		}
+		return false
+	}
+
+	var keep []*zip.CentralDirectoryHeader
+	for _, entry := range r.Files {
+		if !deleted(entry) {
+			keep = append(keep, entry)
+		}
+	}
+	for _, name := range names {
+		if !matched[name] {
+			return fail("delete", fmt.Errorf("no entry named %q in %s", name, archive))
+		}
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+	err = writeArchive(ctx, archive, r, func(zw *zip.ZipWriter) error {
+		for _, entry := range keep {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := zw.Copy(r, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fail("delete", err)
+	}
+	return exitOK
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"GoZip/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// runDiff implements "gozip diff a.zip b.zip". Like diff(1) it exits with
+// 0 when the archives match, 1 when they differ and 2 on error, so CI can
+// fail on unexpected changes.
+func runDiff(args []string) int {
+	fs := newFlagSet("diff", "[--json] [--content] a.zip b.zip")
+	jsonOutput := fs.Bool("json", false, "print the differences as JSON")
+	content := fs.Bool("content", false, "compare entry contents, not only CRC-32 and size")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	a, closeA, err := openArchive(positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gozip diff: %v\n", err)
+		return exitUsage
+	}
+	defer closeA()
+
+	b, closeB, err := openArchive(positional[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gozip diff: %v\n", err)
+		return exitUsage
+	}
+	defer closeB()
+
+	result, err := zip.Diff(a, b, zip.DiffOptions{CompareContent: *content})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gozip diff: %v\n", err)
+		return exitUsage
+	}
+
+	if *jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(os.Stderr, "gozip diff: %v\n", err)
+			return exitUsage
+		}
+	} else {
+		printDiff(result)
+	}
+
+	if result.Empty() {
+		return exitOK
+	}
+	return exitFailure
+}
+
+func printDiff(result *zip.DiffResult) {
+	for _, name := range result.Added {
+		fmt.Printf("+ %s\n", name)
+	}
+	for _, name := range result.Removed {
+		fmt.Printf("- %s\n", name)
+	}
+	for _, entry := range result.Modified {
+		fmt.Printf("M %s\n", entry.Name)
+		for _, change := range entry.Changes {
+			if change.Field == "content" {
+				fmt.Printf("    content differs\n")
+			} else {
+				fmt.Printf("    %s: %s -> %s\n", change.Field, change.Old, change.New)
+			}
+		}
+	}
+	for _, entry := range result.Metadata {
+		fmt.Printf("m %s\n", entry.Name)
+		for _, change := range entry.Changes {
+			fmt.Printf("    %s: %q -> %q\n", change.Field, change.Old, change.New)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"GoZip/zip"
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// stringList is a flag that can be given more than once.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func runExtract(args []string) int {
+	fs := newFlagSet("extract", "[flags] archive.zip")
+	dir := fs.String("d", ".", "extract into `dir`")
+	overwrite := fs.Bool("o", false, "overwrite existing files")
+	var include, exclude, includeFrom, excludeFrom stringList
+	fs.Var(&include, "include", "only extract entries matching `glob` (repeatable, ** matches directories)")
+	fs.Var(&exclude, "exclude", "skip entries matching `glob` (repeatable)")
+	fs.Var(&includeFrom, "include-from", "read include patterns from `file`, one per line")
+	fs.Var(&excludeFrom, "exclude-from", "read exclude patterns from `file`, one per line")
+	listOnly := fs.Bool("list-only", false, "print the entries that would be extracted and stop")
+	jobs := fs.Int("j", runtime.NumCPU(), "extract `n` entries concurrently")
+	failFast := fs.Bool("fail-fast", false, "stop at the first entry that fails instead of reporting all failures")
+	sameOwner := fs.Bool("same-owner", false, "restore the owner and group recorded in the archive (root only)")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	filter := zip.NameFilter{Include: include, Exclude: exclude}
+	for _, path := range includeFrom {
+		patterns, err := readPatterns(path)
+		if err != nil {
+			return fail("extract", err)
+		}
+		filter.Include = append(filter.Include, patterns...)
+	}
+	for _, path := range excludeFrom {
+		patterns, err := readPatterns(path)
+		if err != nil {
+			return fail("extract", err)
+		}
+		filter.Exclude = append(filter.Exclude, patterns...)
+	}
+	for _, pattern := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if err := zip.ValidateGlob(pattern); err != nil {
+			return fail("extract", fmt.Errorf("bad pattern %q: %w", pattern, err))
+		}
+	}
+
+	r, closeArchive, err := openArchive(positional[0])
+	if err != nil {
+		return fail("extract", err)
+	}
+	defer closeArchive()
+
+	selected := func(entry *zip.CentralDirectoryHeader) bool {
+		return filter.Match(entry.Filename)
+	}
+
+	if *listOnly {
+		for _, entry := range r.Files {
+			if selected(entry) {
+				fmt.Println(entry.Filename)
+			}
+		}
+		return exitOK
+	}
+
+	opts := zip.ExtractOptions{
+		Overwrite:    *overwrite,
+		Filter:       selected,
+		Workers:      *jobs,
+		StopOnError:  *failFast,
+		RestoreOwner: *sameOwner,
+	}
+	bar := newProgressBar()
+	if bar != nil {
+		opts.Observer = bar
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	err = r.ExtractAllContext(ctx, *dir, opts)
+	if bar != nil {
+		bar.Finish()
+	}
+	if err != nil {
+		return fail("extract", err)
+	}
+	return exitOK
+}
+
+// readPatterns reads glob patterns from a file, one per line. Blank lines
+// and lines starting with # are ignored.
+func readPatterns(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
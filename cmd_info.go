@@ -0,0 +1,49 @@
+package main
+
+import "fmt"
+
+func runInfo(args []string) int {
+	fs := newFlagSet("info", "archive.zip")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	path := positional[0]
+	r, closeArchive, err := openArchive(path)
+	if err != nil {
+		return fail("info", err)
+	}
+	defer closeArchive()
+
+	var compressed, uncompressed int64
+	files, dirs := 0, 0
+	for _, entry := range r.Files {
+		compressed += int64(entry.CompressedSize)
+		uncompressed += int64(entry.UncompressedSize)
+		if entry.Mode().IsDir() {
+			dirs++
+		} else {
+			files++
+		}
+	}
+
+	fmt.Printf("Archive:            %s\n", path)
+	fmt.Printf("Size:               %d bytes\n", r.Size())
+	fmt.Printf("Entries:            %d (%d files, %d directories)\n", len(r.Files), files, dirs)
+	fmt.Printf("Uncompressed size:  %d bytes\n", uncompressed)
+	fmt.Printf("Compressed size:    %d bytes\n", compressed)
+	if uncompressed > 0 {
+		fmt.Printf("Compression ratio:  %.1f%%\n", 100*(1-float64(compressed)/float64(uncompressed)))
+	}
+	fmt.Printf("Prefix length:      %d bytes\n", r.PrefixLength())
+	fmt.Printf("Central directory:  offset %d, size %d\n", r.EOCD.CentralDirOffset, r.EOCD.CentralDirSize)
+	if r.EOCD.Comment != "" {
+		fmt.Printf("Comment:            %s\n", r.EOCD.Comment)
+	}
+	return exitOK
+}
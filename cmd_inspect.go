@@ -0,0 +1,240 @@
+package main
+
+import (
+	"GoZip/zip"
+	"fmt"
+)
+
+// flagBits names the general purpose flag bits defined by APPNOTE 4.4.4.
+var flagBits = map[int]string{
+	0:  "encrypted",
+	1:  "compression option 1",
+	2:  "compression option 2",
+	3:  "sizes and CRC-32 in data descriptor",
+	4:  "enhanced deflation",
+	5:  "compressed patched data",
+	6:  "strong encryption",
+	11: "UTF-8 names and comments",
+	13: "central directory encrypted, local header values masked",
+}
+
+// hostSystems names the "version made by" host systems from APPNOTE 4.4.2.
+var hostSystems = map[uint16]string{
+	0:  "MS-DOS and OS/2 (FAT)",
+	1:  "Amiga",
+	2:  "OpenVMS",
+	3:  "Unix",
+	4:  "VM/CMS",
+	5:  "Atari ST",
+	6:  "OS/2 HPFS",
+	7:  "Macintosh",
+	8:  "Z-System",
+	9:  "CP/M",
+	10: "Windows NTFS",
+	11: "MVS",
+	12: "VSE",
+	13: "Acorn Risc",
+	14: "VFAT",
+	15: "alternate MVS",
+	16: "BeOS",
+	17: "Tandem",
+	18: "OS/400",
+	19: "OS X (Darwin)",
+}
+
+// extraFieldNames names the extra field header IDs most often seen.
+var extraFieldNames = map[uint16]string{
+	0x0001: "ZIP64 extended information",
+	0x000a: "NTFS",
+	0x000d: "Unix",
+	0x5455: "extended timestamp",
+	0x5855: "Info-ZIP Unix (original)",
+	0x6375: "Info-ZIP Unicode comment",
+	0x7075: "Info-ZIP Unicode path",
+	0x7855: "Info-ZIP Unix",
+	0x7875: "Info-ZIP Unix UID/GID",
+	0x9901: "AE-x encryption",
+	0xcafe: "JAR marker",
+}
+
+func runInspect(args []string) int {
+	fs := newFlagSet("inspect", "archive.zip")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	r, closeArchive, err := openArchive(positional[0])
+	if err != nil {
+		return fail("inspect", err)
+	}
+	defer closeArchive()
+
+	if r.PrefixLength() > 0 {
+		fmt.Printf("%08x  prepended data (%d bytes)\n\n", 0, r.PrefixLength())
+	}
+
+	records, err := r.Records()
+	for _, record := range records {
+		printRecord(record)
+		fmt.Println()
+	}
+	if err != nil {
+		return fail("inspect", err)
+	}
+	return exitOK
+}
+
+// printField prints one decoded field. An empty name continues the
+// previous field on a new line.
+func printField(name string, format string, args ...any) {
+	if name != "" {
+		name += ":"
+	}
+	fmt.Printf("          %-22s %s\n", name, fmt.Sprintf(format, args...))
+}
+
+func printRecord(record zip.Record) {
+	fmt.Printf("%08x  %s (%d bytes)\n", record.Offset, record.Type, record.Size)
+
+	switch h := record.Header.(type) {
+	case *zip.LocalFileHeader:
+		printVersion("version needed", h.VersionNeeded)
+		printFlags(h.Flags)
+		printMethod(h.CompressionMethod)
+		printDOSTime(h.LastModDate, h.LastModTime)
+		printField("crc-32", "%08x", h.CRC32)
+		printField("compressed size", "%d", h.CompressedSize)
+		printField("uncompressed size", "%d", h.UncompressedSize)
+		printField("filename length", "%d", h.FilenameLength)
+		printField("extra field length", "%d", h.ExtraFieldLength)
+		printField("filename", "%q", h.Filename)
+		printExtraFields(h.ExtraField, true)
+		if record.Entry != nil {
+			printField("file data", "%08x, %d bytes", record.Offset+record.Size, record.Entry.CompressedSize)
+		}
+
+	case *zip.DataDescriptor:
+		printField("signature", "%t", h.HasSignature)
+		printField("crc-32", "%08x", h.CRC32)
+		printField("compressed size", "%d", h.CompressedSize)
+		printField("uncompressed size", "%d", h.UncompressedSize)
+
+	case *zip.CentralDirectoryHeader:
+		printVersionMadeBy(h.VersionMadeBy)
+		printVersion("version needed", h.VersionNeeded)
+		printFlags(h.Flags)
+		printMethod(h.CompressionMethod)
+		printDOSTime(h.LastModDate, h.LastModTime)
+		printField("crc-32", "%08x", h.CRC32)
+		printField("compressed size", "%d", h.CompressedSize)
+		printField("uncompressed size", "%d", h.UncompressedSize)
+		printField("filename length", "%d", h.FilenameLength)
+		printField("extra field length", "%d", h.ExtraFieldLength)
+		printField("comment length", "%d", h.CommentLength)
+		printField("disk number start", "%d", h.DiskNumberStart)
+		printField("internal attributes", "%04x", h.InternalAttributes)
+		printField("external attributes", "%08x (%s)", h.ExternalAttributes, h.Mode())
+		printField("local header offset", "%d", h.LocalHeaderOffset)
+		printField("filename", "%q", h.Filename)
+		printExtraFields(h.ExtraField, false)
+		if h.Comment != "" {
+			printField("comment", "%q", h.Comment)
+		}
+
+	case *zip.Zip64EndOfCentralDirectory:
+		printField("record size", "%d", h.RecordSize)
+		printVersionMadeBy(h.VersionMadeBy)
+		printVersion("version needed", h.VersionNeeded)
+		printField("disk number", "%d", h.DiskNumber)
+		printField("disk with cd start", "%d", h.DiskWithCDStart)
+		printField("entries on disk", "%d", h.EntriesOnDisk)
+		printField("total entries", "%d", h.TotalEntries)
+		printField("central dir size", "%d", h.CentralDirSize)
+		printField("central dir offset", "%d", h.CentralDirOffset)
+
+	case *zip.Zip64EndOfCentralDirectoryLocator:
+		printField("disk with zip64 eocd", "%d", h.DiskWithZip64EOCD)
+		printField("zip64 eocd offset", "%d", h.Zip64EOCDOffset)
+		printField("total disks", "%d", h.TotalDisks)
+
+	case *zip.EndOfCentralDirectory:
+		printField("disk number", "%d", h.DiskNumber)
+		printField("disk with cd start", "%d", h.DiskWithCDStart)
+		printField("entries on disk", "%d", h.EntriesOnDisk)
+		printField("total entries", "%d", h.TotalEntries)
+		printField("central dir size", "%d", h.CentralDirSize)
+		printField("central dir offset", "%d", h.CentralDirOffset)
+		printField("comment length", "%d", h.CommentLength)
+		if h.Comment != "" {
+			printField("comment", "%q", h.Comment)
+		}
+	}
+}
+
+func printVersion(name string, version uint16) {
+	printField(name, "%d.%d", version/10, version%10)
+}
+
+func printVersionMadeBy(version uint16) {
+	host := version >> 8
+	name, ok := hostSystems[host]
+	if !ok {
+		name = "unknown"
+	}
+	spec := version & 0xff
+	printField("version made by", "%d.%d, host %d (%s)", spec/10, spec%10, host, name)
+}
+
+func printFlags(flags uint16) {
+	printField("flags", "%04x", flags)
+	for bit := 0; bit < 16; bit++ {
+		if flags&(1<<bit) == 0 {
+			continue
+		}
+		name, ok := flagBits[bit]
+		if !ok {
+			name = "reserved"
+		}
+		printField("", "bit %d: %s", bit, name)
+	}
+}
+
+func printMethod(method uint16) {
+	printField("compression method", "%d (%s)", method, methodName(method))
+}
+
+func printDOSTime(dosDate, dosTime uint16) {
+	day := int(dosDate & 0x1f)
+	month := int(dosDate >> 5 & 0x0f)
+	year := int(dosDate>>9) + 1980
+	hour := int(dosTime >> 11)
+	minute := int(dosTime >> 5 & 0x3f)
+	second := int(dosTime&0x1f) * 2
+	printField("last modified", "%04d-%02d-%02d %02d:%02d:%02d (date %04x, time %04x)",
+		year, month, day, hour, minute, second, dosDate, dosTime)
+}
+
+// printExtraFields lists the header ID and length of each extra field.
+func printExtraFields(extra []byte, local bool) {
+	fields, err := zip.ParseExtra(extra, local)
+	for _, field := range fields {
+		name, ok := extraFieldNames[field.ID()]
+		if !ok {
+			name = "unknown"
+		}
+		data := field.Encode(local)
+		if s, ok := field.(fmt.Stringer); ok {
+			printField("extra field", "%04x %s, %d bytes: %s", field.ID(), name, len(data), s)
+		} else {
+			printField("extra field", "%04x %s, %d bytes: % x", field.ID(), name, len(data), data)
+		}
+	}
+	if err != nil {
+		printField("extra field", "%v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func runLayout(args []string) int {
+	fs := newFlagSet("layout", "[--strict] archive.zip")
+	strict := fs.Bool("strict", false, "exit with status 1 if any region is suspicious")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	r, closeArchive, err := openArchive(positional[0])
+	if err != nil {
+		return fail("layout", err)
+	}
+	defer closeArchive()
+
+	layout, err := r.Layout()
+
+	suspicious := 0
+	fmt.Printf("%-8s  %-8s  %10s  %s\n", "Start", "End", "Size", "Region")
+	for _, region := range layout {
+		marker := "  "
+		if region.Suspicious {
+			marker = "!!"
+			suspicious++
+		}
+		description := region.Kind.String()
+		if region.Name != "" {
+			description += " " + region.Name
+		}
+		fmt.Printf("%08x  %08x  %10d  %s %s\n", region.Start, region.End, region.Size(), marker, description)
+		if region.Note != "" {
+			fmt.Printf("%32s%s\n", "", region.Note)
+		}
+	}
+
+	if err != nil {
+		return fail("layout", err)
+	}
+	if suspicious > 0 {
+		fmt.Fprintf(os.Stderr, "gozip layout: %d suspicious regions\n", suspicious)
+		if *strict {
+			return exitFailure
+		}
+	}
+	return exitOK
+}
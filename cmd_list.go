@@ -0,0 +1,276 @@
+package main
+
+import (
+	"GoZip/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// listEntry is the JSON form of a central directory entry.
+type listEntry struct {
+	Name           string     `json:"name"`
+	Size           uint32     `json:"size"`
+	CompressedSize uint32     `json:"compressed_size"`
+	Ratio          float64    `json:"ratio"`
+	Method         string     `json:"method"`
+	Modified       *time.Time `json:"modified,omitempty"`
+	CRC32          string     `json:"crc32"`
+	Mode           string     `json:"mode"`
+	Comment        string     `json:"comment,omitempty"`
+}
+
+type listTotals struct {
+	Entries        int     `json:"entries"`
+	Size           int64   `json:"size"`
+	CompressedSize int64   `json:"compressed_size"`
+	Ratio          float64 `json:"ratio"`
+}
+
+func runList(args []string) int {
+	fs := newFlagSet("list", "[-l | --json | --tree | --detect-encoding] archive.zip")
+	long := fs.Bool("l", false, "also show CRC-32, permissions and comments")
+	jsonOutput := fs.Bool("json", false, "print the entries as JSON")
+	tree := fs.Bool("tree", false, "group the entries by directory")
+	detect := fs.Bool("detect-encoding", false, "guess the encoding of names not marked as UTF-8 and report it")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	r, closeArchive, err := openArchiveWithOptions(positional[0], zip.ReaderOptions{DetectEncoding: *detect})
+	if err != nil {
+		return fail("list", err)
+	}
+	defer closeArchive()
+
+	if *detect {
+		printEncodingReport(r)
+		return exitOK
+	}
+
+	totals := listTotals{Entries: len(r.Files)}
+	for _, entry := range r.Files {
+		totals.Size += int64(entry.UncompressedSize)
+		totals.CompressedSize += int64(entry.CompressedSize)
+	}
+	totals.Ratio = ratio(totals.CompressedSize, totals.Size)
+
+	switch {
+	case *jsonOutput:
+		err = printListJSON(r, totals)
+	case *tree:
+		printListTree(r, totals)
+	default:
+		printListTable(r, totals, *long)
+	}
+	if err != nil {
+		return fail("list", err)
+	}
+	return exitOK
+}
+
+// formatModified prints a modification time, or "-" for an entry whose
+// time is missing or invalid.
+func formatModified(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// jsonModified returns t for the JSON listing, or nil to leave out the
+// time of an entry whose time is missing or invalid.
+func jsonModified(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// ratio returns the space saved by compression as a percentage.
+func ratio(compressed, uncompressed int64) float64 {
+	if uncompressed == 0 {
+		return 0
+	}
+	return 100 * (1 - float64(compressed)/float64(uncompressed))
+}
+
+func methodName(method uint16) string {
+	switch method {
+	case zip.Store:
+		return "Stored"
+	case zip.Deflate:
+		return "Deflate"
+	default:
+		return fmt.Sprintf("Method%d", method)
+	}
+}
+
+func printListTable(r *zip.Reader, totals listTotals, long bool) {
+	if long {
+		fmt.Printf("%10s %10s %6s  %-7s  %-16s  %-8s  %-10s  %s\n",
+			"Length", "Size", "Ratio", "Method", "Modified", "CRC-32", "Mode", "Name")
+	} else {
+		fmt.Printf("%10s %10s %6s  %-7s  %-16s  %s\n", "Length", "Size", "Ratio", "Method", "Modified", "Name")
+	}
+
+	for _, entry := range r.Files {
+		fmt.Printf("%10d %10d %5.1f%%  %-7s  %-16s  ",
+			entry.UncompressedSize, entry.CompressedSize,
+			ratio(int64(entry.CompressedSize), int64(entry.UncompressedSize)),
+			methodName(entry.CompressionMethod), formatModified(entry.Modified()))
+		if long {
+			fmt.Printf("%08x  %-10s  ", entry.CRC32, entry.Mode())
+		}
+		fmt.Println(entry.Filename)
+		if long && entry.Comment != "" {
+			fmt.Printf("%*s# %s\n", 75, "", entry.Comment)
+		}
+	}
+
+	fmt.Printf("%10d %10d %5.1f%%  %d entries\n", totals.Size, totals.CompressedSize, totals.Ratio, totals.Entries)
+}
+
+func printListJSON(r *zip.Reader, totals listTotals) error {
+	entries := make([]listEntry, 0, len(r.Files))
+	for _, entry := range r.Files {
+		entries = append(entries, listEntry{
+			Name:           entry.Filename,
+			Size:           entry.UncompressedSize,
+			CompressedSize: entry.CompressedSize,
+			Ratio:          ratio(int64(entry.CompressedSize), int64(entry.UncompressedSize)),
+			Method:         methodName(entry.CompressionMethod),
+			Modified:       jsonModified(entry.Modified()),
+			CRC32:          fmt.Sprintf("%08x", entry.CRC32),
+			Mode:           entry.Mode().String(),
+			Comment:        entry.Comment,
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Entries []listEntry `json:"entries"`
+		Totals  listTotals  `json:"totals"`
+	}{entries, totals})
+}
+
+// encodingSamples is how many decoded names the encoding report shows.
+const encodingSamples = 5
+
+// printEncodingReport ranks the candidate encodings for the names the
+// reader had to decode without knowing their encoding and shows some names
+// as the best one decodes them.
+func printEncodingReport(r *zip.Reader) {
+	undetected := r.LegacyEntries()
+	resolved := len(r.Files) - len(undetected)
+	for _, entry := range r.Files {
+		if entry.Flags&0x0800 != 0 {
+			resolved--
+		}
+	}
+	var legacy []*zip.CentralDirectoryHeader
+	for _, entry := range undetected {
+		if slices.ContainsFunc(entry.RawFilename, func(c byte) bool { return c >= 0x80 }) {
+			legacy = append(legacy, entry)
+		}
+	}
+
+	if resolved > 0 {
+		fmt.Printf("%d of %d names resolved by Unicode Path field\n", resolved, len(r.Files))
+	}
+	if len(legacy) == 0 {
+		if resolved > 0 {
+			fmt.Println("All other names are ASCII or marked as UTF-8")
+		} else {
+			fmt.Println("All names are ASCII or marked as UTF-8")
+		}
+		return
+	}
+
+	fmt.Printf("%d of %d names are non-ASCII and not marked as UTF-8\n\n", len(legacy), len(r.Files))
+	fmt.Printf("%-8s  %s\n", "Encoding", "Score")
+	for i, score := range r.DetectEncoding() {
+		switch {
+		case !score.Valid:
+			fmt.Printf("%-8s  invalid\n", score.Encoding.Name)
+		case i == 0:
+			fmt.Printf("%-8s  %5.3f  (chosen)\n", score.Encoding.Name, score.Score)
+		default:
+			fmt.Printf("%-8s  %5.3f\n", score.Encoding.Name, score.Score)
+		}
+	}
+
+	fmt.Println()
+	for _, entry := range legacy[:min(len(legacy), encodingSamples)] {
+		fmt.Printf("  %s\n", entry.Filename)
+	}
+	if len(legacy) > encodingSamples {
+		fmt.Printf("  ... and %d more\n", len(legacy)-encodingSamples)
+	}
+}
+
+// treeNode is a directory or file in the tree view. Directories that have
+// no entry of their own are implied by the names below them.
+type treeNode struct {
+	name     string
+	entry    *zip.CentralDirectoryHeader
+	children map[string]*treeNode
+}
+
+func printListTree(r *zip.Reader, totals listTotals) {
+	root := &treeNode{children: make(map[string]*treeNode)}
+	for _, entry := range r.Files {
+		node := root
+		parts := strings.Split(strings.TrimSuffix(entry.Filename, "/"), "/")
+		for i, part := range parts {
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{name: part, children: make(map[string]*treeNode)}
+				node.children[part] = child
+			}
+			if i == len(parts)-1 {
+				child.entry = entry
+			}
+			node = child
+		}
+	}
+
+	printTreeChildren(root, "")
+	fmt.Printf("\n%d entries, %d bytes (%d compressed, %.1f%% saved)\n",
+		totals.Entries, totals.Size, totals.CompressedSize, totals.Ratio)
+}
+
+func printTreeChildren(node *treeNode, indent string) {
+	names := make([]string, 0, len(node.children))
+	for name := range node.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := node.children[name]
+		branch, nextIndent := "├── ", indent+"│   "
+		if i == len(names)-1 {
+			branch, nextIndent = "└── ", indent+"    "
+		}
+
+		isDir := len(child.children) > 0 || (child.entry != nil && child.entry.Mode().IsDir())
+		switch {
+		case isDir:
+			fmt.Printf("%s%s%s/\n", indent, branch, name)
+		default:
+			fmt.Printf("%s%s%s  (%d bytes)\n", indent, branch, name, child.entry.UncompressedSize)
+		}
+		printTreeChildren(child, nextIndent)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"GoZip/zip"
+	"errors"
+	"fmt"
+	"os"
+)
+
+func runUpdate(args []string) int {
+	fs := newFlagSet("update", "[flags] archive.zip path...")
+	sync := fs.Bool("sync", false, "also remove entries whose files under the paths no longer exist")
+	crc := fs.Bool("crc", false, "also compare CRC-32s to catch changes that keep size and time")
+	store := fs.Bool("store", false, "store new and changed files without compression")
+	quiet := fs.Bool("q", false, "do not list the entries changed")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	opts := zip.UpdateOptions{CompareCRC: *crc, Sync: *sync, Method: zip.Deflate}
+	if *store {
+		opts.Method = zip.Store
+	}
+
+	// A missing archive is created, as with Info-ZIP's -u
+	archive, paths := positional[0], positional[1:]
+	r, closeArchive := &zip.Reader{}, func() {}
+	if _, err := os.Stat(archive); !errors.Is(err, os.ErrNotExist) {
+		var err error
+		r, closeArchive, err = openArchive(archive)
+		if err != nil {
+			return fail("update", err)
+		}
+	}
+	defer closeArchive()
+
+	ctx, stop := interruptContext()
+	defer stop()
+	var result *zip.UpdateResult
+	err := writeArchive(ctx, archive, r, func(zw *zip.ZipWriter) error {
+		var err error
+		result, err = zip.UpdateContext(ctx, zw, r, paths, opts)
+		return err
+	})
+	if err != nil {
+		return fail("update", err)
+	}
+
+	if !*quiet {
+		for _, name := range result.Updated {
+			fmt.Printf("updating: %s\n", name)
+		}
+		for _, name := range result.Added {
+			fmt.Printf("  adding: %s\n", name)
+		}
+		for _, name := range result.Removed {
+			fmt.Printf("deleting: %s\n", name)
+		}
+	}
+	return exitOK
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"GoZip/zip"
+	"fmt"
+	"io"
+	"os"
+)
+
+// runTest implements "gozip test", which decompresses every entry and
+// checks its size and CRC-32 without writing anything. It lives in this
+// file because cmd_test.go would be taken for a test file.
+func runTest(args []string) int {
+	fs := newFlagSet("test", "[-q] archive.zip")
+	quiet := fs.Bool("q", false, "only report failures")
+	positional, code, ok := parseArgs(fs, args)
+	if !ok {
+		return code
+	}
+	if len(positional) != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	r, closeArchive, err := openArchive(positional[0])
+	if err != nil {
+		return fail("test", err)
+	}
+	defer closeArchive()
+
+	failed := 0
+	for _, entry := range r.Files {
+		err := testEntry(r, entry)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "    testing: %-40s FAILED: %v\n", entry.Filename, err)
+		} else if !*quiet {
+			fmt.Printf("    testing: %-40s OK\n", entry.Filename)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "gozip test: %d of %d entries failed\n", failed, len(r.Files))
+		return exitFailure
+	}
+	if !*quiet {
+		fmt.Printf("No errors detected in %s\n", positional[0])
+	}
+	return exitOK
+}
+
+func testEntry(r *zip.Reader, entry *zip.CentralDirectoryHeader) error {
+	rc, err := r.Open(entry)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	_, err = io.Copy(io.Discard, rc)
+	return err
+}
@@ -1,48 +1,72 @@
 package main
 
 import (
-	"GoZip/zip"
+	"fmt"
 	"os"
 )
 
-func main() {
-	// open file
-	//file, err := os.Open("example.zip")
-	//if err != nil {
-	//	panic(err)
-	//}
-	//
-	//defer file.Close()
-	//
-	//zip.ReadZip(file)
-
-	// Write to zip
-	file, err := os.Create("output.zip")
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
+// Exit codes shared by all commands. diff additionally uses exitFailure
+// to mean "the archives differ".
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitMissing = 3 // a named entry is not in the archive
+	exitCorrupt = 4 // an entry failed to decompress or verify
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
 
-	// Create a zip writer
-	zw := zip.NewZipWriter(file)
+var commands = []command{
+	{"create", "create an archive from files and directories", runCreate},
+	{"extract", "extract files from an archive", runExtract},
+	{"list", "list the entries of an archive", runList},
+	{"cat", "write entries to standard output", runCat},
+	{"test", "verify the checksum of every entry", runTest},
+	{"add", "add files to an existing archive", runAdd},
+	{"update", "add new and changed files, copying the rest unchanged", runUpdate},
+	{"delete", "remove entries from an archive", runDelete},
+	{"info", "show a summary of an archive", runInfo},
+	{"inspect", "dump every record with its offset and decoded fields", runInspect},
+	{"layout", "map every byte of an archive and flag hidden data", runLayout},
+	{"diff", "compare two archives", runDiff},
+	{"carve", "find and extract archives embedded in any file", runCarve},
+}
 
-	// Add some files
-	err = zw.AddFile("hello.txt", []byte("Hello, World!"))
-	if err != nil {
-		panic(err)
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: gozip <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "gozip <command> -h" for help on a command.`)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
 	}
 
-	err = zw.AddFile("readme.txt", []byte("This is a readme file."))
-	if err != nil {
-		panic(err)
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		os.Exit(exitOK)
 	}
 
-	// Close the zip writer
-	err = zw.Close()
-	if err != nil {
-		panic(err)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
 	}
 
-	// Print success message
-	println("Zip file created successfully: output.zip")
+	fmt.Fprintf(os.Stderr, "gozip: unknown command %q\n", name)
+	usage()
+	os.Exit(exitUsage)
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// progressInterval is the minimum time between redraws of the bar.
+const progressInterval = 100 * time.Millisecond
+
+// progressBar renders zip.Observer reports as a single line on stderr.
+type progressBar struct {
+	mu       sync.Mutex
+	entries  int
+	finished int
+	total    int64
+	done     int64
+	current  string
+	drawn    time.Time
+	stopped  bool
+}
+
+// newProgressBar returns a progress bar, or nil if stderr is not a
+// terminal and the bar would only clutter logs.
+func newProgressBar() *progressBar {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return &progressBar{}
+}
+
+func (p *progressBar) Start(entries int, totalBytes int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries, p.total = entries, totalBytes
+	p.draw(true)
+}
+
+func (p *progressBar) EntryStart(name string, uncompressedSize int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = name
+	p.draw(false)
+}
+
+func (p *progressBar) Progress(name string, compressed, uncompressed int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += uncompressed
+	p.draw(false)
+}
+
+func (p *progressBar) EntryDone(name string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished++
+	p.draw(p.finished == p.entries)
+}
+
+// Finish clears the bar so that normal output can follow. Later reports
+// are ignored.
+func (p *progressBar) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.drawn.IsZero() {
+		fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+	p.stopped = true
+}
+
+// draw redraws the bar, at most every progressInterval unless force is set.
+func (p *progressBar) draw(force bool) {
+	if p.stopped || (!force && time.Since(p.drawn) < progressInterval) {
+		return
+	}
+	p.drawn = time.Now()
+
+	const width = 30
+	fraction := 1.0
+	if p.total > 0 {
+		fraction = min(float64(p.done)/float64(p.total), 1)
+	}
+	filled := int(fraction * width)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", width-filled)
+
+	name := p.current
+	if len(name) > 40 {
+		name = "..." + name[len(name)-37:]
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K[%s] %3.0f%% %d/%d %s", bar, fraction*100, p.finished, p.entries, name)
+}
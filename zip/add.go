@@ -0,0 +1,101 @@
+package zip
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// AddPaths adds files and directories from disk, recursing into
+// directories. Each entry is named after its path with forward slashes,
+// with any volume name, leading "/" and leading ".." elements removed so
+// that the archive extracts safely. Symlinks and other special files are
+// skipped. It returns the entry names in the order they were added.
+func (zw *ZipWriter) AddPaths(paths []string, method uint16) ([]string, error) {
+	return zw.AddPathsContext(context.Background(), paths, method)
+}
+
+// AddPathsContext is like AddPaths but stops once ctx is done, checking
+// between files and while compressing them. The archive is then incomplete
+// and must be discarded.
+func (zw *ZipWriter) AddPathsContext(ctx context.Context, paths []string, method uint16) ([]string, error) {
+	// Walk everything first so an observer can be told the totals
+	names, sources, err := walkPaths(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	if zw.observer != nil {
+		var total int64
+		for _, src := range sources {
+			if !src.info.IsDir() {
+				total += src.info.Size()
+			}
+		}
+		zw.observer.Start(len(names), total)
+	}
+
+	for i, name := range names {
+		if err := addSource(ctx, zw, name, sources[i], method); err != nil {
+			return names[:i], err
+		}
+	}
+	return names, nil
+}
+
+// walkPaths collects the regular files and directories under paths, in
+// walk order, along with the entry names EntryName gives them.
+func walkPaths(ctx context.Context, paths []string) ([]string, []sourceFile, error) {
+	var names []string
+	var sources []sourceFile
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !d.IsDir() && !d.Type().IsRegular() {
+				return nil // symlinks, devices and the like are not archived
+			}
+
+			name := EntryName(p)
+			if name == "" {
+				return nil // the current directory itself
+			}
+			if d.IsDir() {
+				name += "/"
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+			sources = append(sources, sourceFile{path: p, info: info})
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return names, sources, nil
+}
+
+// EntryName turns a file system path into the relative, slash-separated
+// entry name AddPaths uses for it. It returns "" for the current directory.
+func EntryName(p string) string {
+	p = strings.TrimPrefix(p, filepath.VolumeName(p))
+	name := path.Clean(filepath.ToSlash(p))
+	name = strings.TrimLeft(name, "/")
+	for name == ".." || strings.HasPrefix(name, "../") {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, ".."), "/")
+	}
+	if name == "." {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,156 @@
+package zip
+
+import (
+	"bytes"
+	"io"
+)
+
+// CarvedArchive is a ZIP archive found inside a larger file.
+type CarvedArchive struct {
+	// Start is the offset the archive's own offsets count from. That is
+	// its first local header, unless it is a self-extractor with absolute
+	// offsets, where it is the start of the stub. End is the offset just
+	// past the EOCD comment.
+	Start int64
+	End   int64
+	EOCD  *EndOfCentralDirectory
+	Files []*CentralDirectoryHeader
+}
+
+// Section returns a reader over the bytes of the archive within file,
+// which can be written out as a standalone zip file.
+func (a *CarvedArchive) Section(file io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(file, a.Start, a.End-a.Start)
+}
+
+// CarveResult lists what Carve found.
+type CarveResult struct {
+	Archives []*CarvedArchive
+	// OrphanHeaders are the offsets of local file headers that no valid
+	// archive refers to, such as the remains of an archive whose central
+	// directory was overwritten.
+	OrphanHeaders []int64
+}
+
+// carveChunkSize is how much of the file is scanned at a time.
+const carveChunkSize = 1 << 20
+
+// Carve scans an arbitrary file of fileSize bytes, such as a disk image or
+// firmware blob, for embedded ZIP archives. Every end of central directory
+// signature is a candidate. A candidate is accepted only if its central
+// directory sits immediately before it, holds the number of entries the
+// EOCD claims, and every entry points at a local file header signature
+// found by the scan.
+// The archive's offsets are taken to be relative to its own start, so each
+// archive found can be cut out and read as a standalone file.
+func Carve(file io.ReaderAt, fileSize int64) (*CarveResult, error) {
+	localHeaders, eocds, err := scanSignatures(file, fileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	isLocalHeader := make(map[int64]bool, len(localHeaders))
+	for _, offset := range localHeaders {
+		isLocalHeader[offset] = true
+	}
+
+	result := &CarveResult{}
+	claimed := make(map[int64]bool)
+	for _, eocdPos := range eocds {
+		archive := validateCandidate(file, fileSize, eocdPos, isLocalHeader)
+		if archive == nil {
+			continue
+		}
+		result.Archives = append(result.Archives, archive)
+		for _, entry := range archive.Files {
+			claimed[archive.Start+int64(entry.LocalHeaderOffset)] = true
+		}
+	}
+
+	for _, offset := range localHeaders {
+		if !claimed[offset] {
+			result.OrphanHeaders = append(result.OrphanHeaders, offset)
+		}
+	}
+	return result, nil
+}
+
+// scanSignatures returns the offsets of every local file header and EOCD
+// signature in the file, in order.
+func scanSignatures(file io.ReaderAt, fileSize int64) ([]int64, []int64, error) {
+	localSig := []byte{0x50, 0x4b, 0x03, 0x04}
+	eocdSig := []byte{0x50, 0x4b, 0x05, 0x06}
+
+	var localHeaders, eocds []int64
+	buf := make([]byte, carveChunkSize+3)
+	for start := int64(0); start < fileSize; start += carveChunkSize {
+		// Overlap chunks by three bytes so signatures on a boundary are seen
+		n, err := file.ReadAt(buf, start)
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		chunk := buf[:n]
+
+		for _, sig := range []struct {
+			pattern []byte
+			found   *[]int64
+		}{{localSig, &localHeaders}, {eocdSig, &eocds}} {
+			for i := 0; ; {
+				j := bytes.Index(chunk[i:], sig.pattern)
+				if j < 0 || i+j >= carveChunkSize {
+					break
+				}
+				*sig.found = append(*sig.found, start+int64(i+j))
+				i += j + 1
+			}
+		}
+	}
+	return localHeaders, eocds, nil
+}
+
+// validateCandidate cross-checks the EOCD at eocdPos against the central
+// directory and local headers it refers to, returning nil if they do not
+// agree.
+func validateCandidate(file io.ReaderAt, fileSize, eocdPos int64, isLocalHeader map[int64]bool) *CarvedArchive {
+	if eocdPos+EOCDMinSize > fileSize {
+		return nil
+	}
+	eocd, err := parseEOCD(file, fileSize, eocdPos)
+	if err != nil {
+		return nil
+	}
+	end := eocdPos + EOCDMinSize + int64(eocd.CommentLength)
+	if end > fileSize || eocd.EntriesOnDisk > eocd.TotalEntries {
+		return nil
+	}
+
+	// The central directory ends where the EOCD begins
+	cdStart := eocdPos - int64(eocd.CentralDirSize)
+	base := cdStart - int64(eocd.CentralDirOffset)
+	if cdStart < 0 || base < 0 {
+		return nil
+	}
+
+	archive := &CarvedArchive{Start: base, End: end, EOCD: eocd}
+	offset := cdStart
+	for i := 0; i < int(eocd.TotalEntries); i++ {
+		if offset >= eocdPos {
+			return nil
+		}
+		entry, next, err := readCentralDirectoryEntry(file, fileSize, offset)
+		if err != nil {
+			return nil
+		}
+		headerOffset := base + int64(entry.LocalHeaderOffset)
+		if !isLocalHeader[headerOffset] || headerOffset >= cdStart {
+			return nil
+		}
+		decodeNames(entry, DecodeCP437)
+		archive.Files = append(archive.Files, entry)
+		offset = next
+	}
+	if offset != eocdPos {
+		return nil
+	}
+	return archive
+}
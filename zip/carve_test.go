@@ -0,0 +1,92 @@
+package zip
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCarve(t *testing.T) {
+	build := func(name string) []byte {
+		var buf bytes.Buffer
+		zw := NewZipWriter(&buf)
+		zw.AddFile(name, []byte("contents of "+name))
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first, second := build("first.txt"), build("second.txt")
+
+	// Two archives surrounded by junk, plus a stray signature that does
+	// not validate and a truncated archive missing its central directory
+	var blob bytes.Buffer
+	blob.Write(bytes.Repeat([]byte{0xAA}, 1000))
+	blob.Write(first)
+	blob.Write([]byte("PK\x05\x06 not really an EOCD record"))
+	blob.Write(bytes.Repeat([]byte{0x00}, 300))
+	blob.Write(second)
+	blob.Write(first[:40])
+
+	result, err := Carve(bytes.NewReader(blob.Bytes()), int64(blob.Len()))
+	if err != nil {
+		t.Fatalf("Carve failed: %v", err)
+	}
+
+	if len(result.Archives) != 2 {
+		t.Fatalf("Expected 2 archives, got %d", len(result.Archives))
+	}
+
+	secondStart := int64(1000 + len(first) + len("PK\x05\x06 not really an EOCD record") + 300)
+	expected := []struct {
+		start, end int64
+		name       string
+	}{
+		{1000, int64(1000 + len(first)), "first.txt"},
+		{secondStart, secondStart + int64(len(second)), "second.txt"},
+	}
+	for i, archive := range result.Archives {
+		if archive.Start != expected[i].start || archive.End != expected[i].end {
+			t.Errorf("Archive %d: expected %d-%d, got %d-%d",
+				i, expected[i].start, expected[i].end, archive.Start, archive.End)
+		}
+		if len(archive.Files) != 1 || archive.Files[0].Filename != expected[i].name {
+			t.Errorf("Archive %d: unexpected entries %v", i, archive.Files)
+		}
+	}
+
+	// The carved bytes are a standalone archive
+	carved, err := io.ReadAll(result.Archives[1].Section(bytes.NewReader(blob.Bytes())))
+	if err != nil {
+		t.Fatalf("Failed to read section: %v", err)
+	}
+	if !bytes.Equal(carved, second) {
+		t.Error("Carved archive does not match the original")
+	}
+
+	if len(result.OrphanHeaders) != 1 || result.OrphanHeaders[0] != int64(blob.Len()-40) {
+		t.Errorf("Expected one orphan header at %d, got %v", blob.Len()-40, result.OrphanHeaders)
+	}
+}
+
+func TestCarveDecodesNames(t *testing.T) {
+	raw := []byte("r\x82sum\x82.txt") // "résumé.txt" in CP437
+	data := legacyArchive(t, raw)
+	blob := append(bytes.Repeat([]byte{0xAA}, 100), data...)
+
+	result, err := Carve(bytes.NewReader(blob), int64(len(blob)))
+	if err != nil {
+		t.Fatalf("Carve failed: %v", err)
+	}
+	if len(result.Archives) != 1 {
+		t.Fatalf("Expected 1 archive, got %d", len(result.Archives))
+	}
+	entry := result.Archives[0].Files[0]
+	if entry.Filename != "résumé.txt" {
+		t.Errorf("Expected CP437 name %q, got %q", "résumé.txt", entry.Filename)
+	}
+	if !bytes.Equal(entry.RawFilename, raw) {
+		t.Errorf("Expected raw name % x, got % x", raw, entry.RawFilename)
+	}
+}
@@ -0,0 +1,70 @@
+package zip
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// cp437 holds the characters for bytes 0x80 to 0xff in IBM code page 437,
+// the encoding the spec assigns to names without the UTF-8 flag.
+var cp437 = []rune("" +
+	"ÇüéâäàåçêëèïîìÄÅ" +
+	"ÉæÆôöòûùÿÖÜ¢£¥₧ƒ" +
+	"áíóúñÑªº¿⌐¬½¼¡«»" +
+	"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐" +
+	"└┴┬├─┼╞╟╚╔╩╦╠═╬╧" +
+	"╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀" +
+	"αßΓπΣσµτΦΘΩδ∞φε∩" +
+	"≡±≥≤⌠⌡÷≈°∙·√ⁿ²■\u00a0")
+
+// DecodeCP437 decodes raw as IBM code page 437. Every byte sequence is
+// valid, so it never fails.
+func DecodeCP437(raw []byte) (string, error) {
+	var b strings.Builder
+	b.Grow(len(raw))
+	for _, c := range raw {
+		if c < 0x80 {
+			b.WriteByte(c)
+		} else {
+			b.WriteRune(cp437[c-0x80])
+		}
+	}
+	return b.String(), nil
+}
+
+// isASCII reports whether raw decodes the same in every supported
+// encoding.
+func isASCII(raw []byte) bool {
+	for _, c := range raw {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeNames sets the name and comment of an entry without the UTF-8
+// flag from its raw bytes. Info-ZIP Unicode Path and Comment fields are
+// preferred when their CRC-32 shows they describe the stored bytes;
+// otherwise decode is used. If decode fails, the raw bytes are kept as
+// they are.
+func decodeNames(entry *CentralDirectoryHeader, decode func([]byte) (string, error)) {
+	if entry.Flags&0x0800 != 0 {
+		return
+	}
+	if name, ok := unicodeName(entry); ok {
+		entry.Filename = name
+	} else if !isASCII(entry.RawFilename) {
+		if name, err := decode(entry.RawFilename); err == nil {
+			entry.Filename = name
+		}
+	}
+	if comment, ok := unicodeComment(entry); ok {
+		entry.Comment = comment
+	} else if !isASCII(entry.RawComment) {
+		if comment, err := decode(entry.RawComment); err == nil {
+			entry.Comment = comment
+		}
+	}
+	entry.decodedFilename, entry.decodedComment = entry.Filename, entry.Comment
+}
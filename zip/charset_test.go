@@ -0,0 +1,119 @@
+package zip
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// legacyArchive returns an archive with one entry whose name is raw and
+// whose UTF-8 flag is clear.
+func legacyArchive(t *testing.T, raw []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	// Names that are not valid UTF-8 are written without the flag
+	if err := zw.AddFile(string(raw), []byte("legacy")); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeCP437(t *testing.T) {
+	if len(cp437) != 128 {
+		t.Fatalf("CP437 table has %d entries, expected 128", len(cp437))
+	}
+
+	name, err := DecodeCP437([]byte("caf\x82 \x80\xff.txt"))
+	if err != nil {
+		t.Fatalf("DecodeCP437 failed: %v", err)
+	}
+	if name != "café Ç\u00a0.txt" {
+		t.Errorf("Expected %q, got %q", "café Ç\u00a0.txt", name)
+	}
+}
+
+func TestReaderLegacyNames(t *testing.T) {
+	raw := []byte("r\x82sum\x82.txt") // "résumé.txt" in CP437
+	data := legacyArchive(t, raw)
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	entry := r.Files[0]
+	if entry.Filename != "résumé.txt" {
+		t.Errorf("Expected CP437 name %q, got %q", "résumé.txt", entry.Filename)
+	}
+	if !bytes.Equal(entry.RawFilename, raw) {
+		t.Errorf("Expected raw name % x, got % x", raw, entry.RawFilename)
+	}
+
+	// A caller-supplied decoder replaces CP437
+	upper := func(raw []byte) (string, error) {
+		return strings.ToUpper(string(bytes.ReplaceAll(raw, []byte{0x82}, []byte("e")))), nil
+	}
+	r, err = NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{NameDecoder: upper})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions failed: %v", err)
+	}
+	if r.Files[0].Filename != "RESUME.TXT" {
+		t.Errorf("Expected the custom decoder's name, got %q", r.Files[0].Filename)
+	}
+
+	// Copying keeps the original bytes rather than the decoded name
+	var copied bytes.Buffer
+	zw := NewZipWriter(&copied)
+	if err := zw.Copy(r, r.Files[0]); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	cr, err := NewReader(bytes.NewReader(copied.Bytes()), int64(copied.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if !bytes.Equal(cr.Files[0].RawFilename, raw) {
+		t.Errorf("Expected copied raw name % x, got % x", raw, cr.Files[0].RawFilename)
+	}
+
+	// A renamed copy is written under its new name, as UTF-8
+	renamed := *r.Files[0]
+	renamed.Filename = "résumé-2.txt"
+	copied.Reset()
+	zw = NewZipWriter(&copied)
+	rawData, err := r.OpenRaw(r.Files[0])
+	if err != nil {
+		t.Fatalf("OpenRaw failed: %v", err)
+	}
+	if err := zw.AddRaw(&renamed, rawData); err != nil {
+		t.Fatalf("AddRaw failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	cr, err = NewReader(bytes.NewReader(copied.Bytes()), int64(copied.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if entry := cr.Files[0]; entry.Filename != "résumé-2.txt" || entry.Flags&0x0800 == 0 {
+		t.Errorf("Expected the new UTF-8 name, got %q with flags %04x", entry.Filename, entry.Flags)
+	}
+}
+
+func TestReaderUTF8FlagSkipsDecoding(t *testing.T) {
+	data := writeTestArchive(t, map[string]string{"résumé.txt": "utf-8"})
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if r.Files[0].Filename != "résumé.txt" {
+		t.Errorf("Expected UTF-8 name to be kept, got %q", r.Files[0].Filename)
+	}
+}
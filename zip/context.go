@@ -0,0 +1,26 @@
+package zip
+
+import (
+	"context"
+	"io"
+)
+
+// contextReader fails reads once its context is done, so that copy loops
+// stop promptly.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// contextReadCloser is a contextReader that can be closed.
+type contextReadCloser struct {
+	contextReader
+	io.Closer
+}
@@ -0,0 +1,118 @@
+package zip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// cancellingObserver cancels its context when the named entry starts.
+type cancellingObserver struct {
+	name   string
+	cancel context.CancelFunc
+}
+
+func (o *cancellingObserver) Start(entries int, totalBytes int64) {}
+
+func (o *cancellingObserver) EntryStart(name string, uncompressedSize int64) {
+	if name == o.name {
+		o.cancel()
+	}
+}
+
+func (o *cancellingObserver) Progress(name string, compressed, uncompressed int64) {}
+
+func (o *cancellingObserver) EntryDone(name string, err error) {}
+
+func TestWriterContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.SetObserver(&cancellingObserver{name: "second.txt", cancel: cancel})
+	if err := zw.AddFileContext(ctx, "first.txt", []byte("first"), FileOptions{}); err != nil {
+		t.Fatalf("AddFileContext failed: %v", err)
+	}
+	err := zw.AddFileContext(ctx, "second.txt", bytes.Repeat([]byte("second "), 1000), FileOptions{Method: Deflate})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from AddFileContext, got %v", err)
+	}
+	if err := zw.CloseContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled from CloseContext, got %v", err)
+	}
+}
+
+func TestOpenContext(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFileWith("data.txt", bytes.Repeat([]byte("data "), 10000), FileOptions{Method: Deflate})
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rc, err := r.OpenContext(ctx, r.Files[0])
+	if err != nil {
+		t.Fatalf("OpenContext failed: %v", err)
+	}
+	defer rc.Close()
+
+	if _, err := rc.Read(make([]byte, 100)); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	cancel()
+	if _, err := io.ReadAll(rc); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled after cancelling, got %v", err)
+	}
+	if _, err := r.OpenContext(ctx, r.Files[0]); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled opening with a done context, got %v", err)
+	}
+}
+
+func TestExtractAllContext(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	modified := time.Date(2023, 6, 15, 8, 30, 0, 0, time.Local)
+	zw.AddFileWith("a.txt", []byte("first"), FileOptions{Modified: modified, Mode: 0755})
+	zw.AddFileWith("b.txt", bytes.Repeat([]byte("second "), 1000), FileOptions{Method: Deflate})
+	zw.AddFile("c.txt", []byte("third"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dir := t.TempDir()
+	opts := ExtractOptions{Observer: &cancellingObserver{name: "b.txt", cancel: cancel}}
+	if err := r.ExtractAllContext(ctx, dir, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	// a.txt was written in full, so it keeps its metadata
+	info, err := os.Stat(filepath.Join(dir, "a.txt"))
+	if err != nil {
+		t.Fatalf("a.txt should have been extracted before cancelling: %v", err)
+	}
+	if info.Mode().Perm() != 0755 || !info.ModTime().Equal(modified) {
+		t.Errorf("Expected mode 0755 and time %v, got %v and %v", modified, info.Mode().Perm(), info.ModTime())
+	}
+	for _, name := range []string{"b.txt", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s should not exist after cancelling, got %v", name, err)
+		}
+	}
+}
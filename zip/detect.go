@@ -0,0 +1,262 @@
+package zip
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// Encoding is a character set that legacy entry names may be stored in.
+type Encoding struct {
+	Name string
+	// Decode converts raw bytes to a string, failing if they are not
+	// valid in the encoding.
+	Decode func(raw []byte) (string, error)
+	// plausibility rates how likely a decoded non-ASCII rune is to appear
+	// after prev in a real name written in this encoding, from 0 to 1.
+	plausibility func(prev, r rune) float64
+}
+
+// errInvalidEncoding is returned by Encoding.Decode for bytes that are not
+// valid in the encoding.
+var errInvalidEncoding = errors.New("zip: name is not valid in this encoding")
+
+// Encodings are the candidates DetectEncoding chooses between, in the order
+// it prefers them when they score the same.
+var Encodings = []Encoding{
+	{Name: "UTF-8", Decode: decodeUTF8, plausibility: utf8Plausibility},
+	{Name: "CP437", Decode: DecodeCP437, plausibility: cp437Plausibility},
+	{Name: "CP932", Decode: textDecoder(japanese.ShiftJIS), plausibility: cp932Plausibility},
+	{Name: "GBK", Decode: textDecoder(simplifiedchinese.GBK), plausibility: gbkPlausibility},
+	{Name: "EUC-KR", Decode: textDecoder(korean.EUCKR), plausibility: euckrPlausibility},
+	{Name: "CP1251", Decode: textDecoder(charmap.Windows1251), plausibility: cp1251Plausibility},
+}
+
+// EncodingScore is how well an encoding explains a set of names.
+type EncodingScore struct {
+	Encoding Encoding
+	// Valid is false if some name could not be decoded at all.
+	Valid bool
+	// Score is the mean plausibility of the non-ASCII characters the
+	// names decode to, from 0 to 1.
+	Score float64
+}
+
+// DetectEncoding scores every candidate in Encodings against names taken
+// together, and returns the scores best first. Names are judged together
+// because a single short name is often valid in several encodings, while a
+// whole directory listing rarely is. Names that are plain ASCII say nothing
+// about the encoding and are ignored; if every name is, all candidates
+// score 0 and UTF-8 comes first by preference.
+func DetectEncoding(names [][]byte) []EncodingScore {
+	scores := make([]EncodingScore, len(Encodings))
+	for i, enc := range Encodings {
+		scores[i] = scoreEncoding(enc, names)
+	}
+	slices.SortStableFunc(scores, func(a, b EncodingScore) int {
+		switch {
+		case a.Valid != b.Valid:
+			if a.Valid {
+				return -1
+			}
+			return 1
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	return scores
+}
+
+// scoreEncoding decodes every non-ASCII name with enc and rates the
+// non-ASCII characters it produces.
+func scoreEncoding(enc Encoding, names [][]byte) EncodingScore {
+	score := EncodingScore{Encoding: enc, Valid: true}
+	var total float64
+	var count int
+	for _, raw := range names {
+		if isASCII(raw) {
+			continue
+		}
+		name, err := enc.Decode(raw)
+		if err != nil {
+			score.Valid = false
+			return score
+		}
+		var prev rune
+		for _, r := range name {
+			if r >= utf8.RuneSelf {
+				if unicode.IsControl(r) {
+					score.Valid = false
+					return score
+				}
+				total += enc.plausibility(prev, r)
+				count++
+			}
+			prev = r
+		}
+	}
+	if count > 0 {
+		score.Score = total / float64(count)
+	}
+	return score
+}
+
+// LegacyEntries returns the entries whose names are decoded by
+// ReaderOptions.NameDecoder or by encoding detection: those that neither set
+// the UTF-8 flag nor carry a Unicode Path field matching their raw name.
+func (r *Reader) LegacyEntries() []*CentralDirectoryHeader {
+	var legacy []*CentralDirectoryHeader
+	for _, entry := range r.Files {
+		if entry.Flags&0x0800 != 0 {
+			continue
+		}
+		if _, ok := unicodeName(entry); ok {
+			continue
+		}
+		legacy = append(legacy, entry)
+	}
+	return legacy
+}
+
+// DetectEncoding scores the candidate encodings against the names of the
+// entries returned by LegacyEntries, as described for the package-level
+// DetectEncoding.
+func (r *Reader) DetectEncoding() []EncodingScore {
+	var names [][]byte
+	for _, entry := range r.LegacyEntries() {
+		names = append(names, entry.RawFilename)
+	}
+	return DetectEncoding(names)
+}
+
+// textDecoder returns a strict decoder for enc, which otherwise replaces
+// invalid bytes with U+FFFD.
+func textDecoder(enc encoding.Encoding) func([]byte) (string, error) {
+	return func(raw []byte) (string, error) {
+		decoded, err := enc.NewDecoder().Bytes(raw)
+		if err != nil {
+			return "", err
+		}
+		if strings.ContainsRune(string(decoded), utf8.RuneError) {
+			return "", errInvalidEncoding
+		}
+		return string(decoded), nil
+	}
+}
+
+// decodeUTF8 accepts names that are valid UTF-8 even though the flag that
+// says so is missing, as some tools leave it clear.
+func decodeUTF8(raw []byte) (string, error) {
+	if !utf8.Valid(raw) {
+		return "", errInvalidEncoding
+	}
+	return string(raw), nil
+}
+
+// Multi-byte UTF-8 sequences rarely happen to form in legacy text, so any
+// name that is valid UTF-8 almost certainly is UTF-8.
+func utf8Plausibility(prev, r rune) float64 {
+	return 1
+}
+
+// CP437 is used by Western European DOS and Windows tools, whose names
+// contain accented Latin letters rather than box drawing or Greek, and
+// seldom two of them in a row. Double-byte text decoded as CP437 produces
+// runs of them.
+func cp437Plausibility(prev, r rune) float64 {
+	switch {
+	case unicode.Is(unicode.Latin, r) && prev < utf8.RuneSelf:
+		return 0.9
+	case unicode.Is(unicode.Latin, r):
+		return 0.3
+	case unicode.IsLetter(r):
+		return 0.1
+	case r == 0xa0:
+		return 0.1
+	}
+	return 0.05
+}
+
+// CP1251 names are mostly Russian, which uses only the basic Cyrillic
+// alphabet; the other letters in the upper half of the code page belong to
+// Serbian, Macedonian and Ukrainian. Double-byte text decoded as CP1251
+// produces Russian letters too, but rare ones as often as common ones and
+// capitals in the middle of words.
+func cp1251Plausibility(prev, r rune) float64 {
+	switch {
+	case strings.ContainsRune(commonCyrillic, r):
+		return 1
+	case unicode.IsUpper(r) && unicode.IsLower(prev):
+		return 0.1
+	case r >= 'А' && r <= 'я', r == 'Ё', r == 'ё':
+		return 0.6
+	case unicode.Is(unicode.Cyrillic, r):
+		return 0.4
+	case r == '«', r == '»', r == '–', r == '—', r == '№':
+		return 0.5
+	}
+	return 0.05
+}
+
+// Japanese names are rich in kana. Text in other encodings decoded as
+// CP932 tends to produce half-width katakana instead.
+func cp932Plausibility(prev, r rune) float64 {
+	switch {
+	case unicode.In(r, unicode.Hiragana, unicode.Katakana) && (r < 0xff61 || r > 0xff9f):
+		return 1
+	case unicode.Is(unicode.Han, r):
+		return 0.5
+	case r >= 0xff01 && r <= 0xff5e, r >= 0x3000 && r <= 0x303f:
+		return 0.6
+	}
+	return 0.1
+}
+
+// Chinese names are mostly made of common characters. Korean and Japanese
+// text decoded as GBK also produces Han characters, but seldom these.
+func gbkPlausibility(prev, r rune) float64 {
+	switch {
+	case strings.ContainsRune(commonHanzi, r):
+		return 1
+	case unicode.Is(unicode.Han, r):
+		return 0.4
+	case r >= 0xff01 && r <= 0xff5e, r >= 0x3000 && r <= 0x303f:
+		return 0.6
+	}
+	return 0.1
+}
+
+// Korean names are written in Hangul. Chinese text decoded as EUC-KR also
+// produces Hangul syllables, but seldom common ones.
+func euckrPlausibility(prev, r rune) float64 {
+	switch {
+	case strings.ContainsRune(commonHangul, r):
+		return 1
+	case r >= 0xac00 && r <= 0xd7a3:
+		return 0.5
+	case unicode.Is(unicode.Han, r):
+		return 0.2
+	}
+	return 0.1
+}
+
+// commonCyrillic are the most frequent letters in Russian text.
+const commonCyrillic = "оеаинтсрвлкмдпу"
+
+// commonHanzi are among the most frequent simplified Chinese characters,
+// together with those common in file and folder names.
+const commonHanzi = "的一是不了人我在有他这中大来上国个到说们为子和你地出道也时年得就那要下以生会自着去之过家学对可她里后小么心多天而能好都然没日于起还发成事只作当想看文无开手十用主行方又如前所本见经头面公同三已老从动两长知民样现分将外但身些与高意进把法此实回二理美点月明其种声全工己话儿者向情部正名定女问力机给等几很业最间新什打便位因重被走电四第门相次东政海口使教西再平真听世气信北少关并内加化由却代军产入先山五太水万市眼体别处总才场师书比住员九笑性通目华报立马命张活难神数件安表原车白应路期叫死常提感金何更反合放做系计或司利受光王果亲界及今京务制解各任至清物台象记边共风战干接它许八特觉望直服毛林题建南度统色字请交爱让认算论百吃义科怎元社术结六功指思非流每青管夫连远资队跟带花快条院变联言权往展该领传近留红治决周保达办运武半候七必城父强步完革深区即求品士转量空甚众技轻程告江语英基派满式李息写呢识极令黄德收脸钱党倒未持取设始版双历越史商千片容研像找友孩站广改议形委早房音火际则首单据导影失拿网香似斯专石若兵弟谁校读志飞观争究包组造落视济喜离虽坏兴档夹图备份录项目"
+
+// commonHangul are among the most frequent Hangul syllables.
+const commonHangul = "이다의는에을가하고지기사리자대한로도서수정인보시일부상제나어전아요신중적해주면라국구동성게소장그화개용위여마우비원문유내스니오학금세경실연거저무관있것들에서때를만업선발통과회터드명번분공교생진방모월음운치반계물파현식영안변결초단행표간근재미차형호설료청심불임작"
@@ -0,0 +1,130 @@
+package zip
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// encodeNames encodes every name with enc.
+func encodeNames(t *testing.T, enc encoding.Encoding, names ...string) [][]byte {
+	t.Helper()
+
+	var raw [][]byte
+	for _, name := range names {
+		b, err := enc.NewEncoder().Bytes([]byte(name))
+		if err != nil {
+			t.Fatalf("Encoding %q failed: %v", name, err)
+		}
+		raw = append(raw, b)
+	}
+	return raw
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		expected string
+		names    [][]byte
+	}{
+		{"CP932", encodeNames(t, japanese.ShiftJIS, "資料/", "資料/会議のメモ.txt", "写真/さくら.jpg", "readme.txt")},
+		{"CP1251", encodeNames(t, charmap.Windows1251, "Документы/", "Документы/отчёт за год.docx", "Фото.png")},
+		{"GBK", encodeNames(t, simplifiedchinese.GBK, "新建文件夹/", "新建文件夹/工作报告.doc", "数据.xls")},
+		{"EUC-KR", encodeNames(t, korean.EUCKR, "문서/", "문서/회의 자료.hwp", "사진.jpg")},
+		{"CP437", encodeNames(t, charmap.CodePage437, "Übersicht.txt", "résumé.doc", "Señor/")},
+		{"UTF-8", [][]byte{[]byte("Документы/"), []byte("会議のメモ.txt")}},
+	}
+
+	for _, test := range tests {
+		scores := DetectEncoding(test.names)
+		if got := scores[0].Encoding.Name; got != test.expected {
+			t.Errorf("Expected %s, got %s: %+v", test.expected, got, scores)
+		}
+	}
+
+	// Without non-ASCII names every candidate ties and preference decides
+	scores := DetectEncoding([][]byte{[]byte("plain.txt")})
+	if scores[0].Encoding.Name != "UTF-8" || scores[0].Score != 0 {
+		t.Errorf("Expected UTF-8 with no evidence, got %+v", scores[0])
+	}
+}
+
+func TestReaderDetectEncoding(t *testing.T) {
+	raw := encodeNames(t, japanese.ShiftJIS, "会議のメモ.txt")[0]
+	data := legacyArchive(t, raw)
+
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{DetectEncoding: true})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions failed: %v", err)
+	}
+	if name := r.Files[0].Filename; name != "会議のメモ.txt" {
+		t.Errorf("Expected detected name %q, got %q", "会議のメモ.txt", name)
+	}
+	if scores := r.DetectEncoding(); scores[0].Encoding.Name != "CP932" {
+		t.Errorf("Expected CP932, got %+v", scores)
+	}
+
+	// Without the option the spec's CP437 is used
+	r, err = NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if name := r.Files[0].Filename; name == "会議のメモ.txt" {
+		t.Errorf("Expected CP437 name without detection, got %q", name)
+	}
+
+	// With no candidates detection falls back to CP437
+	saved := Encodings
+	defer func() { Encodings = saved }()
+	Encodings = nil
+	r, err = NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{DetectEncoding: true})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions failed: %v", err)
+	}
+	if expected, _ := DecodeCP437(raw); r.Files[0].Filename != expected {
+		t.Errorf("Expected CP437 name %q, got %q", expected, r.Files[0].Filename)
+	}
+}
+
+func TestReaderLegacyEntries(t *testing.T) {
+	raw := encodeNames(t, japanese.ShiftJIS, "会議のメモ.txt", "写真/さくら.jpg", "資料/報告書.doc", "資料/予定表.xls")
+
+	// Two names carry a Unicode Path field, two have only legacy bytes
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	for i, name := range []string{"会議のメモ.txt", "写真/さくら.jpg"} {
+		if err := zw.AddFileWith(name, []byte("data"), FileOptions{LegacyName: raw[i]}); err != nil {
+			t.Fatalf("AddFileWith failed: %v", err)
+		}
+	}
+	for _, name := range raw[2:] {
+		if err := zw.AddFile(string(name), []byte("data")); err != nil {
+			t.Fatalf("AddFile failed: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	data := buf.Bytes()
+
+	r, err := NewReaderWithOptions(bytes.NewReader(data), int64(len(data)), ReaderOptions{DetectEncoding: true})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions failed: %v", err)
+	}
+	legacy := r.LegacyEntries()
+	if len(legacy) != 2 || legacy[0] != r.Files[2] || legacy[1] != r.Files[3] {
+		t.Fatalf("Expected the last two entries as legacy, got %d", len(legacy))
+	}
+	if scores := r.DetectEncoding(); scores[0].Encoding.Name != "CP932" {
+		t.Errorf("Expected CP932, got %+v", scores)
+	}
+	for i, expected := range []string{"会議のメモ.txt", "写真/さくら.jpg", "資料/報告書.doc", "資料/予定表.xls"} {
+		if name := r.Files[i].Filename; name != expected {
+			t.Errorf("Expected name %q, got %q", expected, name)
+		}
+	}
+}
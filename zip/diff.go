@@ -0,0 +1,172 @@
+package zip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DiffOptions controls how Diff compares two archives.
+type DiffOptions struct {
+	// CompareContent decompresses entries whose CRC-32 and size match and
+	// compares their bytes, rather than trusting the checksum.
+	CompareContent bool
+}
+
+// FieldChange is one field that differs between two versions of an entry.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// EntryDiff lists the changes to an entry present in both archives.
+type EntryDiff struct {
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// DiffResult is the difference between two archives. Modified holds
+// entries whose content changed; Metadata holds entries whose content is
+// the same but whose modification time, mode, method or comment changed.
+type DiffResult struct {
+	Added    []string    `json:"added"`
+	Removed  []string    `json:"removed"`
+	Modified []EntryDiff `json:"modified"`
+	Metadata []EntryDiff `json:"metadata"`
+}
+
+// Empty reports whether the archives had no differences.
+func (d *DiffResult) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0 && len(d.Metadata) == 0
+}
+
+// Diff compares the entries of a and b by name. Content changes are
+// detected by CRC-32 and uncompressed size, plus a byte comparison when
+// opts.CompareContent is set.
+func Diff(a, b *Reader, opts DiffOptions) (*DiffResult, error) {
+	result := &DiffResult{
+		Added:    []string{},
+		Removed:  []string{},
+		Modified: []EntryDiff{},
+		Metadata: []EntryDiff{},
+	}
+
+	inB := make(map[string]*CentralDirectoryHeader, len(b.Files))
+	for _, entry := range b.Files {
+		inB[entry.Filename] = entry
+	}
+	inA := make(map[string]bool, len(a.Files))
+
+	for _, oldEntry := range a.Files {
+		inA[oldEntry.Filename] = true
+
+		newEntry, ok := inB[oldEntry.Filename]
+		if !ok {
+			result.Removed = append(result.Removed, oldEntry.Filename)
+			continue
+		}
+
+		var content []FieldChange
+		if oldEntry.UncompressedSize != newEntry.UncompressedSize {
+			content = append(content, FieldChange{"size",
+				fmt.Sprint(oldEntry.UncompressedSize), fmt.Sprint(newEntry.UncompressedSize)})
+		}
+		if oldEntry.CRC32 != newEntry.CRC32 {
+			content = append(content, FieldChange{"crc32",
+				fmt.Sprintf("%08x", oldEntry.CRC32), fmt.Sprintf("%08x", newEntry.CRC32)})
+		}
+		if len(content) == 0 && opts.CompareContent {
+			same, err := sameContent(a, oldEntry, b, newEntry)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				content = append(content, FieldChange{"content", "", ""})
+			}
+		}
+		if len(content) > 0 {
+			result.Modified = append(result.Modified, EntryDiff{oldEntry.Filename, content})
+		}
+
+		if meta := metadataChanges(oldEntry, newEntry); len(meta) > 0 {
+			result.Metadata = append(result.Metadata, EntryDiff{oldEntry.Filename, meta})
+		}
+	}
+
+	for _, entry := range b.Files {
+		if !inA[entry.Filename] {
+			result.Added = append(result.Added, entry.Filename)
+		}
+	}
+
+	return result, nil
+}
+
+// formatModified prints the modification time of entry, or "-" if it has
+// none that is valid.
+func formatModified(entry *CentralDirectoryHeader) string {
+	modified := entry.Modified()
+	if modified.IsZero() {
+		return "-"
+	}
+	return modified.Format(time.DateTime)
+}
+
+func metadataChanges(oldEntry, newEntry *CentralDirectoryHeader) []FieldChange {
+	var changes []FieldChange
+
+	if oldEntry.LastModDate != newEntry.LastModDate || oldEntry.LastModTime != newEntry.LastModTime {
+		changes = append(changes, FieldChange{"mtime", formatModified(oldEntry), formatModified(newEntry)})
+	}
+	if oldEntry.Mode() != newEntry.Mode() {
+		changes = append(changes, FieldChange{"mode", oldEntry.Mode().String(), newEntry.Mode().String()})
+	}
+	if oldEntry.CompressionMethod != newEntry.CompressionMethod {
+		changes = append(changes, FieldChange{"method",
+			fmt.Sprint(oldEntry.CompressionMethod), fmt.Sprint(newEntry.CompressionMethod)})
+	}
+	if oldEntry.Comment != newEntry.Comment {
+		changes = append(changes, FieldChange{"comment", oldEntry.Comment, newEntry.Comment})
+	}
+
+	return changes
+}
+
+// sameContent decompresses both entries and compares them chunk by chunk.
+func sameContent(a *Reader, oldEntry *CentralDirectoryHeader, b *Reader, newEntry *CentralDirectoryHeader) (bool, error) {
+	oldData, err := a.Open(oldEntry)
+	if err != nil {
+		return false, err
+	}
+	defer oldData.Close()
+
+	newData, err := b.Open(newEntry)
+	if err != nil {
+		return false, err
+	}
+	defer newData.Close()
+
+	oldBuf := make([]byte, 32*1024)
+	newBuf := make([]byte, 32*1024)
+	for {
+		n1, err1 := io.ReadFull(oldData, oldBuf)
+		n2, err2 := io.ReadFull(newData, newBuf)
+		if !bytes.Equal(oldBuf[:n1], newBuf[:n2]) {
+			return false, nil
+		}
+
+		done1 := err1 == io.EOF || err1 == io.ErrUnexpectedEOF
+		done2 := err2 == io.EOF || err2 == io.ErrUnexpectedEOF
+		if err1 != nil && !done1 {
+			return false, err1
+		}
+		if err2 != nil && !done2 {
+			return false, err2
+		}
+		if done1 || done2 {
+			return done1 == done2, nil
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package zip
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 6, 0, time.Local)
+
+	build := func(files map[string]FileOptions) *Reader {
+		var buf bytes.Buffer
+		zw := NewZipWriter(&buf)
+		for _, name := range []string{"same.txt", "content.txt", "meta.txt", "old.txt", "new.txt"} {
+			opts, ok := files[name]
+			if !ok {
+				continue
+			}
+			data := "data for " + name
+			if name == "content.txt" && opts.Method == Deflate {
+				data = strings.Repeat("different ", 20) // long enough to deflate
+			}
+			opts.Modified = modified
+			if err := zw.AddFileWith(name, []byte(data), opts); err != nil {
+				t.Fatalf("AddFileWith failed: %v", err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		return r
+	}
+
+	a := build(map[string]FileOptions{
+		"same.txt":    {},
+		"content.txt": {},
+		"meta.txt":    {Mode: 0644},
+		"old.txt":     {},
+	})
+	b := build(map[string]FileOptions{
+		"same.txt":    {},
+		"content.txt": {Method: Deflate},
+		"meta.txt":    {Mode: 0600},
+		"new.txt":     {},
+	})
+
+	result, err := Diff(a, b, DiffOptions{CompareContent: true})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if !slices.Equal(result.Added, []string{"new.txt"}) {
+		t.Errorf("Unexpected added entries: %v", result.Added)
+	}
+	if !slices.Equal(result.Removed, []string{"old.txt"}) {
+		t.Errorf("Unexpected removed entries: %v", result.Removed)
+	}
+	if len(result.Modified) != 1 || result.Modified[0].Name != "content.txt" {
+		t.Errorf("Unexpected modified entries: %v", result.Modified)
+	}
+	if len(result.Metadata) != 2 {
+		t.Fatalf("Expected metadata changes for 2 entries, got %v", result.Metadata)
+	}
+	if result.Metadata[0].Name != "content.txt" || result.Metadata[0].Changes[0].Field != "method" {
+		t.Errorf("Expected a method change for content.txt, got %v", result.Metadata[0])
+	}
+	if result.Metadata[1].Name != "meta.txt" || result.Metadata[1].Changes[0].Field != "mode" {
+		t.Errorf("Expected a mode change for meta.txt, got %v", result.Metadata[1])
+	}
+
+	same, err := Diff(a, a, DiffOptions{CompareContent: true})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if !same.Empty() {
+		t.Errorf("Expected no differences comparing an archive with itself, got %+v", same)
+	}
+}
+
+func TestMetadataChangesInvalidTime(t *testing.T) {
+	valid := &CentralDirectoryHeader{LastModDate: 44<<9 | 5<<5 | 1, LastModTime: 12 << 11}
+	invalid := &CentralDirectoryHeader{LastModDate: 44<<9 | 13<<5 | 1, LastModTime: 12 << 11}
+
+	changes := metadataChanges(valid, invalid)
+	if len(changes) != 1 || changes[0].Field != "mtime" {
+		t.Fatalf("Expected an mtime change, got %v", changes)
+	}
+	if changes[0].New != "-" {
+		t.Errorf("Expected an invalid time to print as -, got %q", changes[0].New)
+	}
+	if changes[0].Old != "2024-05-01 12:00:00" {
+		t.Errorf("Expected the valid time, got %q", changes[0].Old)
+	}
+}
@@ -0,0 +1,99 @@
+package zip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrFormat is matched by every error caused by a malformed archive,
+	// including *FormatError values.
+	ErrFormat = errors.New("zip: not a valid zip file")
+	// ErrChecksum means an entry's data does not match its CRC-32.
+	ErrChecksum = errors.New("zip: checksum error")
+	// ErrAlgorithm means an entry uses an unsupported compression method.
+	ErrAlgorithm = errors.New("zip: unsupported compression algorithm")
+	// ErrInsecurePath means an entry name or symlink target would escape
+	// the extraction directory.
+	ErrInsecurePath = errors.New("zip: insecure file path")
+	// ErrTooLarge means a value does not fit in its field without ZIP64.
+	ErrTooLarge = errors.New("zip: value too large")
+)
+
+// FormatError describes a malformed record. It matches ErrFormat with
+// errors.Is, and any underlying cause, such as io.ErrUnexpectedEOF for a
+// truncated record, can be reached with errors.Unwrap.
+type FormatError struct {
+	// Record is the type of the record that is malformed.
+	Record RecordType
+	// Offset is the absolute file offset of the record, or -1 if the
+	// record could not be located.
+	Offset int64
+	// Entry is the name of the entry the record belongs to, if known.
+	Entry string
+	// Field names the offending field, if a single one is at fault.
+	Field string
+	// Expected and Actual hold the values that disagree, when the problem
+	// is a mismatch.
+	Expected, Actual any
+	// Err is the underlying cause, if any.
+	Err error
+}
+
+func (e *FormatError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "zip: invalid %s", e.Record)
+	if e.Offset >= 0 {
+		fmt.Fprintf(&b, " at offset %d", e.Offset)
+	}
+	if e.Entry != "" {
+		fmt.Fprintf(&b, " for %s", e.Entry)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, ": %s", e.Field)
+	}
+	if e.Expected != nil || e.Actual != nil {
+		fmt.Fprintf(&b, ": expected %s, got %s", formatValue(e.Expected), formatValue(e.Actual))
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, ": %v", e.Err)
+	}
+	return b.String()
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrFormat.
+func (e *FormatError) Is(target error) bool {
+	return target == ErrFormat
+}
+
+// formatValue prints signatures in hex and anything else as is.
+func formatValue(v any) string {
+	if sig, ok := v.(uint32); ok {
+		return fmt.Sprintf("0x%08x", sig)
+	}
+	return fmt.Sprint(v)
+}
+
+// truncated turns the error from reading a record into a *FormatError if
+// the record was cut short, and otherwise returns it unchanged.
+func truncated(record RecordType, offset int64, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &FormatError{Record: record, Offset: offset, Err: io.ErrUnexpectedEOF}
+	}
+	return err
+}
+
+// withEntry records the name of entry in err if it is a *FormatError.
+func withEntry(err error, entry *CentralDirectoryHeader) error {
+	var fe *FormatError
+	if errors.As(err, &fe) && fe.Entry == "" {
+		fe.Entry = entry.Filename
+	}
+	return err
+}
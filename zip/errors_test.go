@@ -0,0 +1,148 @@
+package zip
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"testing"
+)
+
+// writeTestArchive returns an archive holding the given files, stored.
+func writeTestArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	for name, content := range files {
+		if err := zw.AddFile(name, []byte(content)); err != nil {
+			t.Fatalf("AddFile failed: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFormatErrors(t *testing.T) {
+	data := writeTestArchive(t, map[string]string{"a.txt": "hello"})
+
+	// Too short to hold an EOCD record
+	_, err := NewReader(bytes.NewReader(data[:10]), 10)
+	if !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for a tiny file, got %v", err)
+	}
+
+	// A corrupted local header signature is reported with its offset
+	corrupt := append([]byte{}, data...)
+	corrupt[0] = 'X'
+	r, err := NewReader(bytes.NewReader(corrupt), int64(len(corrupt)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	_, err = r.Open(r.Files[0])
+	var fe *FormatError
+	if !errors.As(err, &fe) {
+		t.Fatalf("Expected a *FormatError, got %v", err)
+	}
+	if !errors.Is(err, ErrFormat) {
+		t.Error("FormatError should match ErrFormat")
+	}
+	if fe.Record != RecordLocalHeader || fe.Offset != 0 || fe.Entry != "a.txt" || fe.Field != "signature" {
+		t.Errorf("Unexpected error details: %+v", fe)
+	}
+	if fe.Expected != uint32(LocalFileHeaderSignature) || fe.Actual != uint32(0x04034b58) {
+		t.Errorf("Expected signature mismatch, got expected %v, actual %v", fe.Expected, fe.Actual)
+	}
+
+	// A central directory cut short is a truncated record
+	eocd := bytes.LastIndex(data, []byte("PK\x05\x06"))
+	truncatedData := append(append([]byte{}, data[:eocd-10]...), data[eocd:]...)
+	_, err = NewReader(bytes.NewReader(truncatedData), int64(len(truncatedData)))
+	if !errors.Is(err, ErrFormat) {
+		t.Errorf("Expected ErrFormat for a truncated central directory, got %v", err)
+	}
+}
+
+func TestChecksumError(t *testing.T) {
+	data := writeTestArchive(t, map[string]string{"a.txt": "hello"})
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	// Flip the first byte of the stored data
+	_, dataOffset, err := readLocalFileHeader(bytes.NewReader(data), int64(len(data)), 0)
+	if err != nil {
+		t.Fatalf("Failed to read local header: %v", err)
+	}
+	data[dataOffset] ^= 0xff
+	rc, err := r.Open(r.Files[0])
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer rc.Close()
+
+	if _, err := io.ReadAll(rc); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Expected ErrChecksum, got %v", err)
+	}
+}
+
+func TestCorruptDeflateError(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	if err := zw.AddFileWith("a.txt", bytes.Repeat([]byte("hello "), 100), FileOptions{Method: Deflate}); err != nil {
+		t.Fatalf("AddFileWith failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	data := buf.Bytes()
+
+	// A final block of the reserved type is invalid DEFLATE
+	_, dataOffset, err := readLocalFileHeader(bytes.NewReader(data), int64(len(data)), 0)
+	if err != nil {
+		t.Fatalf("Failed to read local header: %v", err)
+	}
+	data[dataOffset] = 0xff
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	rc, err := r.Open(r.Files[0])
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer rc.Close()
+
+	_, err = io.ReadAll(rc)
+	var fe *FormatError
+	if !errors.As(err, &fe) || !errors.Is(err, ErrFormat) {
+		t.Fatalf("Expected a *FormatError, got %v", err)
+	}
+	if fe.Record != RecordLocalHeader || fe.Offset != 0 || fe.Entry != "a.txt" {
+		t.Errorf("Unexpected error details: %+v", fe)
+	}
+	var corrupt flate.CorruptInputError
+	if !errors.As(err, &corrupt) {
+		t.Errorf("Expected the flate error to be kept, got %v", err)
+	}
+}
+
+func TestAlgorithmError(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	err := zw.AddFileWith("a.txt", []byte("hello"), FileOptions{Method: 99})
+	if !errors.Is(err, ErrAlgorithm) {
+		t.Errorf("Expected ErrAlgorithm, got %v", err)
+	}
+}
+
+func TestInsecurePathError(t *testing.T) {
+	_, err := safeJoin("out", "../etc/passwd")
+	if !errors.Is(err, ErrInsecurePath) {
+		t.Errorf("Expected ErrInsecurePath, got %v", err)
+	}
+}
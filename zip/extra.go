@@ -0,0 +1,156 @@
+package zip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// ExtraField is one field of the extra field list that follows the name
+// in local and central directory headers. Each field is stored as a
+// 2-byte header ID, a 2-byte length and that many bytes of data.
+type ExtraField interface {
+	// ID returns the header ID of the field.
+	ID() uint16
+	// Encode returns the data of the field, without the 4-byte header, for
+	// a local header if local is set or for a central directory header
+	// otherwise. Some fields carry less in the central directory.
+	Encode(local bool) []byte
+}
+
+// ExtraDecoder decodes the data of an extra field. local reports whether
+// the field came from a local header.
+type ExtraDecoder func(data []byte, local bool) (ExtraField, error)
+
+var (
+	extraDecodersMu sync.RWMutex
+	extraDecoders   = make(map[uint16]ExtraDecoder)
+)
+
+// RegisterExtraDecoder registers dec for fields with the header ID id,
+// replacing any decoder already registered for it. Fields without a
+// decoder are returned as *RawExtra.
+func RegisterExtraDecoder(id uint16, dec ExtraDecoder) {
+	extraDecodersMu.Lock()
+	defer extraDecodersMu.Unlock()
+	extraDecoders[id] = dec
+}
+
+// RawExtra is an extra field kept as it was found, either because no
+// decoder is registered for its ID or because the decoder rejected it.
+type RawExtra struct {
+	HeaderID uint16
+	Data     []byte
+}
+
+func (e *RawExtra) ID() uint16 { return e.HeaderID }
+
+func (e *RawExtra) Encode(local bool) []byte { return e.Data }
+
+func (e *RawExtra) String() string { return fmt.Sprintf("% x", e.Data) }
+
+// ExtraFieldError reports a malformed extra field. It matches ErrFormat
+// with errors.Is.
+type ExtraFieldError struct {
+	// ID is the header ID of the field, if its header could be read.
+	ID uint16
+	// Offset is the position of the field within the extra field data.
+	Offset int
+	// Err describes what is wrong with the field.
+	Err error
+}
+
+func (e *ExtraFieldError) Error() string {
+	return fmt.Sprintf("zip: invalid extra field %04x at offset %d: %v", e.ID, e.Offset, e.Err)
+}
+
+func (e *ExtraFieldError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrFormat.
+func (e *ExtraFieldError) Is(target error) bool {
+	return target == ErrFormat
+}
+
+// ParseExtra splits extra field data into fields and decodes those with a
+// registered decoder. local reports whether the data came from a local
+// header. Fields that fail to decode are kept as *RawExtra and reported
+// with an *ExtraFieldError; parsing carries on with the next field. If the
+// list itself is malformed, the fields before the damage are returned
+// along with the error.
+func ParseExtra(data []byte, local bool) ([]ExtraField, error) {
+	var fields []ExtraField
+	var firstErr error
+
+	for offset := 0; offset < len(data); {
+		if len(data)-offset < 4 {
+			return fields, firstOf(firstErr, &ExtraFieldError{Offset: offset,
+				Err: fmt.Errorf("%d trailing bytes are too short for a field header", len(data)-offset)})
+		}
+		id := binary.LittleEndian.Uint16(data[offset:])
+		size := int(binary.LittleEndian.Uint16(data[offset+2:]))
+		body := data[offset+4:]
+		if size > len(body) {
+			return fields, firstOf(firstErr, &ExtraFieldError{ID: id, Offset: offset,
+				Err: fmt.Errorf("%d bytes declared but only %d present", size, len(body))})
+		}
+		body = body[:size:size]
+
+		extraDecodersMu.RLock()
+		dec := extraDecoders[id]
+		extraDecodersMu.RUnlock()
+
+		var field ExtraField = &RawExtra{HeaderID: id, Data: body}
+		if dec != nil {
+			decoded, err := dec(body, local)
+			if err != nil {
+				firstErr = firstOf(firstErr, &ExtraFieldError{ID: id, Offset: offset, Err: err})
+			} else {
+				field = decoded
+			}
+		}
+		fields = append(fields, field)
+		offset += 4 + size
+	}
+
+	return fields, firstErr
+}
+
+// firstOf returns err unless first is already set.
+func firstOf(first, err error) error {
+	if first != nil {
+		return first
+	}
+	return err
+}
+
+// encodeExtra encodes fields for a local header if local is set or for a
+// central directory header otherwise.
+func encodeExtra(fields []ExtraField, local bool) ([]byte, error) {
+	var out []byte
+	for _, field := range fields {
+		data := field.Encode(local)
+		if len(data) > math.MaxUint16 {
+			return nil, fmt.Errorf("%w: extra field %04x is %d bytes", ErrTooLarge, field.ID(), len(data))
+		}
+		out = binary.LittleEndian.AppendUint16(out, field.ID())
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(data)))
+		out = append(out, data...)
+	}
+	if len(out) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: extra fields take %d bytes", ErrTooLarge, len(out))
+	}
+	return out, nil
+}
+
+// Extra parses the extra fields of the local header.
+func (h *LocalFileHeader) Extra() ([]ExtraField, error) {
+	return ParseExtra(h.ExtraField, true)
+}
+
+// Extra parses the extra fields of the central directory header.
+func (h *CentralDirectoryHeader) Extra() ([]ExtraField, error) {
+	return ParseExtra(h.ExtraField, false)
+}
@@ -0,0 +1,177 @@
+package zip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Header IDs of the Info-ZIP extra fields decoded by this package.
+const (
+	ExtendedTimestampID = 0x5455 // "UT"
+	UnixOwnerID         = 0x7875 // "ux"
+)
+
+func init() {
+	RegisterExtraDecoder(ExtendedTimestampID, decodeExtendedTimestamp)
+	RegisterExtraDecoder(UnixOwnerID, decodeUnixOwner)
+}
+
+// ExtendedTimestamp is the Info-ZIP extended timestamp field, which holds
+// times as Unix seconds in UTC, free of the 2-second resolution, missing
+// time zone and 1980 epoch of DOS times. Zero times are absent. Central
+// directory headers only ever carry the modification time.
+type ExtendedTimestamp struct {
+	ModTime    time.Time
+	AccessTime time.Time
+	CreateTime time.Time
+}
+
+func (e *ExtendedTimestamp) ID() uint16 { return ExtendedTimestampID }
+
+func (e *ExtendedTimestamp) Encode(local bool) []byte {
+	times := []time.Time{e.ModTime, e.AccessTime, e.CreateTime}
+
+	// The flags list every time present in the local header, even in the
+	// central directory where only the modification time follows
+	var flags byte
+	for i, t := range times {
+		if representable(t) {
+			flags |= 1 << i
+		}
+	}
+
+	data := []byte{flags}
+	for i, t := range times {
+		if flags&(1<<i) == 0 || (!local && i > 0) {
+			continue
+		}
+		data = binary.LittleEndian.AppendUint32(data, uint32(int32(t.Unix())))
+	}
+	return data
+}
+
+func (e *ExtendedTimestamp) String() string {
+	s := "mtime " + formatExtraTime(e.ModTime)
+	if !e.AccessTime.IsZero() {
+		s += ", atime " + formatExtraTime(e.AccessTime)
+	}
+	if !e.CreateTime.IsZero() {
+		s += ", ctime " + formatExtraTime(e.CreateTime)
+	}
+	return s
+}
+
+// representable reports whether t is set and fits in the signed 32-bit
+// seconds the extended timestamp stores.
+func representable(t time.Time) bool {
+	return !t.IsZero() && t.Unix() >= math.MinInt32 && t.Unix() <= math.MaxInt32
+}
+
+func formatExtraTime(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func decodeExtendedTimestamp(data []byte, local bool) (ExtraField, error) {
+	if len(data) < 1 {
+		return nil, errors.New("missing flags")
+	}
+	flags, data := data[0], data[1:]
+
+	e := &ExtendedTimestamp{}
+	times := []*time.Time{&e.ModTime, &e.AccessTime, &e.CreateTime}
+	for i, t := range times {
+		if flags&(1<<i) == 0 {
+			continue
+		}
+		if len(data) < 4 {
+			if local {
+				return nil, fmt.Errorf("flags %02x promise more times than the %d bytes hold", flags, len(data))
+			}
+			break // the central directory only has the modification time
+		}
+		*t = time.Unix(int64(int32(binary.LittleEndian.Uint32(data))), 0)
+		data = data[4:]
+	}
+	return e, nil
+}
+
+// UnixOwner is the Info-ZIP Unix UID/GID field ("ux"), which records the
+// numeric owner and group of a file.
+type UnixOwner struct {
+	UID int
+	GID int
+}
+
+func (e *UnixOwner) ID() uint16 { return UnixOwnerID }
+
+func (e *UnixOwner) Encode(local bool) []byte {
+	data := []byte{1, 4} // version, UID size
+	data = binary.LittleEndian.AppendUint32(data, uint32(e.UID))
+	data = append(data, 4) // GID size
+	return binary.LittleEndian.AppendUint32(data, uint32(e.GID))
+}
+
+func (e *UnixOwner) String() string {
+	return fmt.Sprintf("uid %d, gid %d", e.UID, e.GID)
+}
+
+func decodeUnixOwner(data []byte, local bool) (ExtraField, error) {
+	// Some archivers leave the central directory copy empty
+	if len(data) == 0 {
+		return &RawExtra{HeaderID: UnixOwnerID, Data: data}, nil
+	}
+	if data[0] != 1 {
+		return nil, fmt.Errorf("unsupported version %d", data[0])
+	}
+	data = data[1:]
+
+	var ids [2]int
+	for i := range ids {
+		if len(data) < 1 {
+			return nil, errors.New("truncated")
+		}
+		size := int(data[0])
+		if size > 8 || len(data) < 1+size {
+			return nil, fmt.Errorf("bad ID size %d", size)
+		}
+		var id uint64
+		for j := size - 1; j >= 0; j-- {
+			id = id<<8 | uint64(data[1+j])
+		}
+		if id > math.MaxInt32 {
+			return nil, fmt.Errorf("ID %d out of range", id)
+		}
+		ids[i] = int(id)
+		data = data[1+size:]
+	}
+	return &UnixOwner{UID: ids[0], GID: ids[1]}, nil
+}
+
+// findExtra returns the first field of type T among the extra fields in
+// data, ignoring any that are malformed.
+func findExtra[T ExtraField](data []byte, local bool) (T, bool) {
+	fields, _ := ParseExtra(data, local)
+	for _, field := range fields {
+		if f, ok := field.(T); ok {
+			return f, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Owner returns the numeric owner and group recorded in the entry's
+// Info-ZIP UID/GID extra field, if it has one.
+func (h *CentralDirectoryHeader) Owner() (uid, gid int, ok bool) {
+	owner, ok := findExtra[*UnixOwner](h.ExtraField, false)
+	if !ok {
+		return 0, 0, false
+	}
+	return owner.UID, owner.GID, true
+}
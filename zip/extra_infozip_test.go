@@ -0,0 +1,100 @@
+package zip
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestExtendedTimestamp(t *testing.T) {
+	// Before the DOS epoch and at an odd second, neither of which DOS
+	// times can hold
+	modified := time.Date(1975, 3, 4, 12, 0, 1, 0, time.UTC)
+	accessed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	err := zw.AddFileWith("old.txt", []byte("old"), FileOptions{
+		Modified: modified,
+		Accessed: accessed,
+		Owner:    &UnixOwner{UID: 1234, GID: 5678},
+	})
+	if err != nil {
+		t.Fatalf("AddFileWith failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	entry := r.Files[0]
+
+	if !entry.Modified().Equal(modified) {
+		t.Errorf("Expected modification time %v, got %v", modified, entry.Modified())
+	}
+	if uid, gid, ok := entry.Owner(); !ok || uid != 1234 || gid != 5678 {
+		t.Errorf("Expected owner 1234:5678, got %d:%d (found: %v)", uid, gid, ok)
+	}
+
+	// Only the local header carries the access time
+	lh, _, err := readLocalFileHeader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), r.LocalHeaderOffset(entry))
+	if err != nil {
+		t.Fatalf("Failed to read local header: %v", err)
+	}
+	ut, ok := findExtra[*ExtendedTimestamp](lh.ExtraField, true)
+	if !ok || !ut.AccessTime.Equal(accessed) || !ut.ModTime.Equal(modified) {
+		t.Errorf("Expected local extended timestamp with both times, got %+v", ut)
+	}
+}
+
+func TestReproducibleOmitsHostExtras(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	if err := zw.SetReproducible(); err != nil {
+		t.Fatalf("SetReproducible failed: %v", err)
+	}
+	zw.AddFileWith("a.txt", []byte("a"), FileOptions{Owner: &UnixOwner{UID: 1, GID: 1}})
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if len(r.Files[0].ExtraField) != 0 {
+		t.Errorf("Expected no extra fields in reproducible mode, got % x", r.Files[0].ExtraField)
+	}
+}
+
+func TestNoTimestamp(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFileWith("default.txt", []byte("a"), FileOptions{Extra: []ExtraField{}})
+	zw.AddFileWith("none.txt", []byte("a"), FileOptions{NoTimestamp: true})
+	zw.AddFileWith("owner.txt", []byte("a"), FileOptions{NoTimestamp: true, Owner: &UnixOwner{UID: 1, GID: 1}})
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if _, ok := findExtra[*ExtendedTimestamp](r.Files[0].ExtraField, false); !ok {
+		t.Error("Expected an extended timestamp by default, even with an empty Extra")
+	}
+	if len(r.Files[1].ExtraField) != 0 {
+		t.Errorf("Expected no extra fields with NoTimestamp, got % x", r.Files[1].ExtraField)
+	}
+	fields, err := r.Files[2].Extra()
+	if err != nil || len(fields) != 1 {
+		t.Fatalf("Expected only the UID/GID field, got %v (%v)", fields, err)
+	}
+	if _, ok := fields[0].(*UnixOwner); !ok {
+		t.Errorf("Expected a UID/GID field, got %#v", fields[0])
+	}
+}
@@ -0,0 +1,115 @@
+package zip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NTFSID is the header ID of the NTFS extra field.
+const NTFSID = 0x000a
+
+func init() {
+	RegisterExtraDecoder(NTFSID, decodeNTFS)
+}
+
+// ntfsTimesTag is the attribute in the NTFS extra field that holds the
+// modification, access and creation times.
+const ntfsTimesTag = 0x0001
+
+// ticksTo1970 is the number of 100-nanosecond intervals between the
+// Windows FILETIME epoch, 1601-01-01 UTC, and the Unix epoch.
+const ticksTo1970 = 116444736000000000
+
+// NTFSTimes is the NTFS extra field written by Windows archivers, which
+// keeps file times to 100 nanoseconds. Zero times are absent. Attributes
+// other than the times are kept so the field encodes back unchanged.
+type NTFSTimes struct {
+	ModTime    time.Time
+	AccessTime time.Time
+	CreateTime time.Time
+
+	reserved uint32
+	other    []byte // attributes other than the times, still encoded
+}
+
+func (e *NTFSTimes) ID() uint16 { return NTFSID }
+
+func (e *NTFSTimes) Encode(local bool) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, e.reserved)
+	data = binary.LittleEndian.AppendUint16(data, ntfsTimesTag)
+	data = binary.LittleEndian.AppendUint16(data, 24)
+	for _, t := range []time.Time{e.ModTime, e.AccessTime, e.CreateTime} {
+		data = binary.LittleEndian.AppendUint64(data, timeToFiletime(t))
+	}
+	return append(data, e.other...)
+}
+
+func (e *NTFSTimes) String() string {
+	return fmt.Sprintf("mtime %s, atime %s, ctime %s",
+		formatNTFSTime(e.ModTime), formatNTFSTime(e.AccessTime), formatNTFSTime(e.CreateTime))
+}
+
+func formatNTFSTime(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func decodeNTFS(data []byte, local bool) (ExtraField, error) {
+	if len(data) < 4 {
+		return nil, errors.New("missing reserved bytes")
+	}
+	e := &NTFSTimes{reserved: binary.LittleEndian.Uint32(data)}
+	data = data[4:]
+
+	found := false
+	for len(data) > 0 {
+		if len(data) < 4 {
+			return nil, fmt.Errorf("%d trailing bytes are too short for an attribute header", len(data))
+		}
+		tag := binary.LittleEndian.Uint16(data)
+		size := int(binary.LittleEndian.Uint16(data[2:]))
+		if size > len(data)-4 {
+			return nil, fmt.Errorf("attribute %04x declares %d bytes but only %d present", tag, size, len(data)-4)
+		}
+		body := data[4 : 4+size]
+
+		if tag == ntfsTimesTag && !found {
+			if size < 24 {
+				return nil, fmt.Errorf("times attribute is %d bytes, expected 24", size)
+			}
+			e.ModTime = filetimeToTime(binary.LittleEndian.Uint64(body))
+			e.AccessTime = filetimeToTime(binary.LittleEndian.Uint64(body[8:]))
+			e.CreateTime = filetimeToTime(binary.LittleEndian.Uint64(body[16:]))
+			found = true
+		} else {
+			e.other = append(e.other, data[:4+size]...)
+		}
+		data = data[4+size:]
+	}
+	if !found {
+		return nil, errors.New("no times attribute")
+	}
+	return e, nil
+}
+
+// filetimeToTime converts a Windows FILETIME, with 0 meaning unset.
+func filetimeToTime(ft uint64) time.Time {
+	if ft == 0 {
+		return time.Time{}
+	}
+	ticks := int64(ft - ticksTo1970) // negative before 1970
+	return time.Unix(ticks/1e7, ticks%1e7*100)
+}
+
+// timeToFiletime converts a time to a Windows FILETIME, with the zero time
+// becoming 0.
+func timeToFiletime(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.Unix()*1e7 + int64(t.Nanosecond()/100) + ticksTo1970)
+}
@@ -0,0 +1,85 @@
+package zip
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestNTFSTimes(t *testing.T) {
+	modified := time.Date(2023, 6, 15, 8, 30, 1, 123456700, time.UTC)
+	created := time.Date(1601, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.SetNTFSTimes(true)
+	if err := zw.AddFileWith("exact.txt", []byte("exact"), FileOptions{Modified: modified, Created: created}); err != nil {
+		t.Fatalf("AddFileWith failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	entry := r.Files[0]
+
+	// Only the NTFS field keeps the fraction of a second
+	if !entry.Modified().Equal(modified) {
+		t.Errorf("Expected modification time %v, got %v", modified, entry.Modified())
+	}
+	ntfs, ok := findExtra[*NTFSTimes](entry.ExtraField, false)
+	if !ok {
+		t.Fatal("Expected an NTFS extra field")
+	}
+	if !ntfs.CreateTime.Equal(created) || !ntfs.AccessTime.IsZero() {
+		t.Errorf("Expected creation time %v and no access time, got %v and %v", created, ntfs.CreateTime, ntfs.AccessTime)
+	}
+	if !bytes.Equal(ntfs.Encode(false), (&NTFSTimes{ModTime: modified, CreateTime: created}).Encode(false)) {
+		t.Error("NTFS field does not encode back to the same bytes")
+	}
+}
+
+func TestModifiedPrecedence(t *testing.T) {
+	dos := time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local)
+	ut := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	ntfs := time.Date(2022, 1, 1, 0, 0, 0, 500, time.UTC)
+
+	tests := []struct {
+		name     string
+		extra    []ExtraField
+		expected time.Time
+	}{
+		{"extended timestamp over DOS", []ExtraField{&ExtendedTimestamp{ModTime: ut}}, ut},
+		{"NTFS over extended timestamp", []ExtraField{&ExtendedTimestamp{ModTime: ut}, &NTFSTimes{ModTime: ntfs}}, ntfs},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		zw := NewZipWriter(&buf)
+		if err := zw.AddFileWith("a.txt", []byte("a"), FileOptions{Modified: dos, Extra: tt.extra}); err != nil {
+			t.Fatalf("%s: AddFileWith failed: %v", tt.name, err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("%s: Close failed: %v", tt.name, err)
+		}
+
+		r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("%s: NewReader failed: %v", tt.name, err)
+		}
+		if got := r.Files[0].Modified(); !got.Equal(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestFiletime(t *testing.T) {
+	if ft := timeToFiletime(time.Unix(0, 0)); ft != 116444736000000000 {
+		t.Errorf("Expected the Unix epoch at 116444736000000000, got %d", ft)
+	}
+	if got := filetimeToTime(1); !got.Equal(time.Date(1601, 1, 1, 0, 0, 0, 100, time.UTC)) {
+		t.Errorf("Expected 100ns after 1601-01-01, got %v", got)
+	}
+}
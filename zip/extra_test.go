@@ -0,0 +1,114 @@
+package zip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testExtra is a field whose central directory form is shorter than its
+// local form, as with the extended timestamp.
+type testExtra struct {
+	value uint16
+}
+
+const testExtraID = 0xfe01
+
+func (e *testExtra) ID() uint16 { return testExtraID }
+
+func (e *testExtra) Encode(local bool) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, e.value)
+	if local {
+		data = append(data, "local"...)
+	}
+	return data
+}
+
+func TestExtraFields(t *testing.T) {
+	RegisterExtraDecoder(testExtraID, func(data []byte, local bool) (ExtraField, error) {
+		if len(data) < 2 {
+			return nil, errors.New("too short")
+		}
+		return &testExtra{value: binary.LittleEndian.Uint16(data)}, nil
+	})
+
+	unknown := &RawExtra{HeaderID: 0xfe02, Data: []byte("kept as is")}
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	err := zw.AddFileWith("a.txt", []byte("hello"), FileOptions{Extra: []ExtraField{&testExtra{value: 42}, unknown}, NoTimestamp: true})
+	if err != nil {
+		t.Fatalf("AddFileWith failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	entry := r.Files[0]
+
+	fields, err := entry.Extra()
+	if err != nil {
+		t.Fatalf("Extra failed: %v", err)
+	}
+	if len(fields) != 2 {
+		t.Fatalf("Expected 2 extra fields, got %d", len(fields))
+	}
+	if f, ok := fields[0].(*testExtra); !ok || f.value != 42 {
+		t.Errorf("Expected decoded test field with value 42, got %#v", fields[0])
+	}
+	if f, ok := fields[1].(*RawExtra); !ok || f.HeaderID != 0xfe02 || !bytes.Equal(f.Data, unknown.Data) {
+		t.Errorf("Expected unknown field preserved, got %#v", fields[1])
+	}
+
+	// The local header carries the longer form
+	lh, _, err := readLocalFileHeader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), r.LocalHeaderOffset(entry))
+	if err != nil {
+		t.Fatalf("Failed to read local header: %v", err)
+	}
+	if len(lh.ExtraField) != len(entry.ExtraField)+len("local") {
+		t.Errorf("Expected local extra to be 5 bytes longer than central (%d), got %d", len(entry.ExtraField), len(lh.ExtraField))
+	}
+
+	// Copying the entry raw keeps its extra fields byte for byte
+	var copied bytes.Buffer
+	cw := NewZipWriter(&copied)
+	if err := cw.Copy(r, entry); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	cr, err := NewReader(bytes.NewReader(copied.Bytes()), int64(copied.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if !bytes.Equal(cr.Files[0].ExtraField, entry.ExtraField) {
+		t.Error("Extra fields changed when copying")
+	}
+}
+
+func TestParseExtraMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		fields int
+	}{
+		{"truncated header", []byte{0x02, 0xfe, 0x00, 0x00, 0x01, 0x02}, 1},
+		{"length past end", []byte{0x02, 0xfe, 0x10, 0x00, 0x01}, 0},
+	}
+	for _, tt := range tests {
+		fields, err := ParseExtra(tt.data, false)
+		var fe *ExtraFieldError
+		if !errors.As(err, &fe) || !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: expected an *ExtraFieldError, got %v", tt.name, err)
+		}
+		if len(fields) != tt.fields {
+			t.Errorf("%s: expected %d fields before the damage, got %d", tt.name, tt.fields, len(fields))
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package zip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"unicode/utf8"
+)
+
+// Header IDs of the Info-ZIP Unicode extra fields.
+const (
+	UnicodePathID    = 0x7075 // "up"
+	UnicodeCommentID = 0x6375 // "uc"
+)
+
+func init() {
+	RegisterExtraDecoder(UnicodePathID, decodeUnicodePath)
+	RegisterExtraDecoder(UnicodeCommentID, decodeUnicodeComment)
+}
+
+// UnicodePath is the Info-ZIP Unicode Path extra field. It carries the
+// UTF-8 form of a name stored in a legacy encoding, along with the CRC-32
+// of the stored name so that readers can tell whether a tool that did not
+// understand the field has since renamed the entry.
+type UnicodePath struct {
+	CRC32 uint32 // of the name in the header
+	Name  string
+}
+
+func (e *UnicodePath) ID() uint16 { return UnicodePathID }
+
+func (e *UnicodePath) Encode(local bool) []byte {
+	return encodeUnicodeField(e.CRC32, e.Name)
+}
+
+func (e *UnicodePath) String() string {
+	return fmt.Sprintf("%q (crc-32 %08x)", e.Name, e.CRC32)
+}
+
+// UnicodeComment is the Info-ZIP Unicode Comment extra field, the comment
+// counterpart of UnicodePath.
+type UnicodeComment struct {
+	CRC32   uint32 // of the comment in the header
+	Comment string
+}
+
+func (e *UnicodeComment) ID() uint16 { return UnicodeCommentID }
+
+func (e *UnicodeComment) Encode(local bool) []byte {
+	return encodeUnicodeField(e.CRC32, e.Comment)
+}
+
+func (e *UnicodeComment) String() string {
+	return fmt.Sprintf("%q (crc-32 %08x)", e.Comment, e.CRC32)
+}
+
+func encodeUnicodeField(crc uint32, text string) []byte {
+	data := []byte{1} // version
+	data = binary.LittleEndian.AppendUint32(data, crc)
+	return append(data, text...)
+}
+
+// decodeUnicodeField decodes the version, CRC-32 and UTF-8 text shared by
+// both Unicode fields.
+func decodeUnicodeField(data []byte) (uint32, string, error) {
+	if len(data) < 5 {
+		return 0, "", errors.New("too short")
+	}
+	if data[0] != 1 {
+		return 0, "", fmt.Errorf("unsupported version %d", data[0])
+	}
+	if !utf8.Valid(data[5:]) {
+		return 0, "", errors.New("text is not valid UTF-8")
+	}
+	return binary.LittleEndian.Uint32(data[1:]), string(data[5:]), nil
+}
+
+func decodeUnicodePath(data []byte, local bool) (ExtraField, error) {
+	crc, name, err := decodeUnicodeField(data)
+	if err != nil {
+		return nil, err
+	}
+	return &UnicodePath{CRC32: crc, Name: name}, nil
+}
+
+func decodeUnicodeComment(data []byte, local bool) (ExtraField, error) {
+	crc, comment, err := decodeUnicodeField(data)
+	if err != nil {
+		return nil, err
+	}
+	return &UnicodeComment{CRC32: crc, Comment: comment}, nil
+}
+
+// unicodeName returns the UTF-8 name from the entry's Unicode Path field,
+// if it has one that still matches the stored name.
+func unicodeName(entry *CentralDirectoryHeader) (string, bool) {
+	up, ok := findExtra[*UnicodePath](entry.ExtraField, false)
+	if !ok || up.CRC32 != crc32.ChecksumIEEE(entry.RawFilename) {
+		return "", false
+	}
+	return up.Name, true
+}
+
+// unicodeComment is the comment counterpart of unicodeName.
+func unicodeComment(entry *CentralDirectoryHeader) (string, bool) {
+	uc, ok := findExtra[*UnicodeComment](entry.ExtraField, false)
+	if !ok || uc.CRC32 != crc32.ChecksumIEEE(entry.RawComment) {
+		return "", false
+	}
+	return uc.Comment, true
+}
@@ -0,0 +1,104 @@
+package zip
+
+import (
+	"bytes"
+	"hash/crc32"
+	"testing"
+)
+
+func TestUnicodePath(t *testing.T) {
+	legacy := []byte("r\x82sum\x82.txt") // "résumé.txt" in CP437
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	if err := zw.AddFileWith("résumé-ü.txt", []byte("cv"), FileOptions{LegacyName: legacy}); err != nil {
+		t.Fatalf("AddFileWith failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	entry := r.Files[0]
+	if entry.Flags&0x0800 != 0 {
+		t.Error("Expected the UTF-8 flag to be clear for a legacy name")
+	}
+	if !bytes.Equal(entry.RawFilename, legacy) {
+		t.Errorf("Expected raw name % x, got % x", legacy, entry.RawFilename)
+	}
+	// The Unicode path wins over the CP437 decoding of the stored name
+	if entry.Filename != "résumé-ü.txt" {
+		t.Errorf("Expected the Unicode path, got %q", entry.Filename)
+	}
+}
+
+func TestUnicodePathOptions(t *testing.T) {
+	legacy := []byte("r\x82sum\x82.txt")
+
+	// The UTF-8 name must be valid to be stored in the field
+	zw := NewZipWriter(&bytes.Buffer{})
+	if err := zw.AddFileWith("r\xe9sum\xe9.txt", []byte("cv"), FileOptions{LegacyName: legacy}); err == nil {
+		t.Error("Expected an error for a legacy name with a non-UTF-8 name")
+	}
+
+	// A field supplied by the caller is written instead of the automatic one
+	var buf bytes.Buffer
+	zw = NewZipWriter(&buf)
+	up := &UnicodePath{CRC32: crc32.ChecksumIEEE(legacy), Name: "résumé (final).txt"}
+	opts := FileOptions{LegacyName: legacy, Extra: []ExtraField{up}}
+	if err := zw.AddFileWith("résumé.txt", []byte("cv"), opts); err != nil {
+		t.Fatalf("AddFileWith failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	fields, err := r.Files[0].Extra()
+	if err != nil {
+		t.Fatalf("Extra failed: %v", err)
+	}
+	var count int
+	for _, field := range fields {
+		if field.ID() == UnicodePathID {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected one Unicode Path field, got %d", count)
+	}
+	if r.Files[0].Filename != up.Name {
+		t.Errorf("Expected the supplied Unicode path, got %q", r.Files[0].Filename)
+	}
+}
+
+func TestUnicodeFieldsStale(t *testing.T) {
+	// A tool that did not know the fields renamed the entry afterwards,
+	// so their CRC-32s no longer match and the stored bytes are decoded
+	entry := &CentralDirectoryHeader{
+		RawFilename: []byte("n\x81.txt"),
+		RawComment:  []byte("\x84"),
+	}
+	extra, err := encodeExtra([]ExtraField{
+		&UnicodePath{CRC32: crc32.ChecksumIEEE([]byte("old name")), Name: "stale.txt"},
+		&UnicodeComment{CRC32: crc32.ChecksumIEEE(entry.RawComment), Comment: "ä comment"},
+	}, false)
+	if err != nil {
+		t.Fatalf("encodeExtra failed: %v", err)
+	}
+	entry.ExtraField = extra
+
+	decodeNames(entry, DecodeCP437)
+	if entry.Filename != "nü.txt" {
+		t.Errorf("Expected the stale Unicode path to be ignored, got %q", entry.Filename)
+	}
+	if entry.Comment != "ä comment" {
+		t.Errorf("Expected the matching Unicode comment, got %q", entry.Comment)
+	}
+}
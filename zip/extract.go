@@ -0,0 +1,424 @@
+package zip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ExtractOptions controls how ExtractAll writes entries to disk.
+type ExtractOptions struct {
+	// Overwrite replaces existing files. Without it, extracting over an
+	// existing file is an error.
+	Overwrite bool
+	// Filter, if set, selects the entries to extract. It sees only the
+	// central directory entry, so skipped entries are never read.
+	Filter func(entry *CentralDirectoryHeader) bool
+	// Workers is the number of entries decompressed and written at once.
+	// Values below 1 mean one.
+	Workers int
+	// StopOnError stops at the first failed entry. Otherwise every entry
+	// is attempted and all failures are returned together.
+	StopOnError bool
+	// RestoreOwner sets the owner and group of extracted files from their
+	// Info-ZIP UID/GID extra fields. It only takes effect when running as
+	// root, since other users cannot give files away.
+	RestoreOwner bool
+	// Observer, if set, receives progress reports for the files and
+	// symlinks written. Directories are not reported.
+	Observer Observer
+}
+
+// Find returns the entry called name, or nil if there is none.
+func (r *Reader) Find(name string) *CentralDirectoryHeader {
+	for _, entry := range r.Files {
+		if entry.Filename == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+// extractJob is an entry to extract and the path it goes to.
+type extractJob struct {
+	entry  *CentralDirectoryHeader
+	target string
+}
+
+// ExtractAll extracts every entry of the archive into dir, creating it if
+// needed. Entry names that would escape dir (absolute paths, ".."
+// elements, backslashes) are rejected.
+//
+// Extraction runs in three phases. All directories are created first, so
+// the workers never race to create them. Then up to opts.Workers files are
+// decompressed and written concurrently, followed by the symlinks, one at
+// a time, so that no file is written through a link the archive created.
+// Finally permissions and modification times are applied, directories last
+// and deepest first, so that writing their contents does not disturb them.
+//
+// Entries inside a symlink entry are rejected, and nothing is written
+// through a symlink already on disk below dir, so a chain of links cannot
+// lead outside dir either.
+//
+// Failures are reported per entry and returned joined together with
+// errors.Join, unless opts.StopOnError is set.
+func (r *Reader) ExtractAll(dir string, opts ExtractOptions) error {
+	return r.ExtractAllContext(context.Background(), dir, opts)
+}
+
+// ExtractAllContext is like ExtractAll but stops once ctx is done. No new
+// entries are started and files being written are abandoned and removed.
+// The entries already written still get their metadata, and the context's
+// error is returned.
+func (r *Reader) ExtractAllContext(ctx context.Context, dir string, opts ExtractOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var errs []error
+	var dirs, files, links []extractJob
+	symlinks := make(map[string]bool)
+	for _, entry := range r.Files {
+		if entry.Mode()&os.ModeSymlink != 0 {
+			symlinks[path.Clean(entry.Filename)] = true
+		}
+	}
+	for _, entry := range r.Files {
+		if opts.Filter != nil && !opts.Filter(entry) {
+			continue
+		}
+
+		target, err := safeJoin(dir, entry.Filename)
+		if err == nil {
+			err = insideSymlink(entry.Filename, symlinks)
+		}
+		if err != nil {
+			if opts.StopOnError {
+				return err
+			}
+			errs = append(errs, err)
+			continue
+		}
+
+		switch {
+		case entry.Mode().IsDir():
+			dirs = append(dirs, extractJob{entry, target})
+		case entry.Mode()&os.ModeSymlink != 0:
+			links = append(links, extractJob{entry, target})
+		default:
+			files = append(files, extractJob{entry, target})
+		}
+	}
+
+	// 1. Create directories, including ones only implied by file names
+	created := make(map[string]bool)
+	mkdir := func(path string) error {
+		if created[path] {
+			return nil
+		}
+		created[path] = true
+		if err := checkSymlinks(dir, path); err != nil {
+			return err
+		}
+		return os.MkdirAll(path, 0755)
+	}
+	for _, job := range dirs {
+		if err := mkdir(job.target); err != nil {
+			return err
+		}
+	}
+	for _, job := range slices.Concat(files, links) {
+		if err := mkdir(filepath.Dir(job.target)); err != nil {
+			return err
+		}
+	}
+
+	// 2. Write files, then symlinks
+	if opts.Observer != nil {
+		var total int64
+		for _, job := range slices.Concat(files, links) {
+			total += int64(job.entry.UncompressedSize)
+		}
+		opts.Observer.Start(len(files)+len(links), total)
+	}
+	extracted := make(map[*CentralDirectoryHeader]bool)
+	fileErrs := r.extractFiles(ctx, dir, files, opts, extracted)
+	if len(fileErrs) > 0 && opts.StopOnError {
+		return fileErrs[0]
+	}
+	errs = append(errs, fileErrs...)
+	linkOpts := opts
+	linkOpts.Workers = 1
+	linkErrs := r.extractFiles(ctx, dir, links, linkOpts, extracted)
+	if len(linkErrs) > 0 && opts.StopOnError {
+		return linkErrs[0]
+	}
+	errs = append(errs, linkErrs...)
+
+	// 3. Apply ownership, permissions and modification times, even after
+	// cancellation so that what was written is left complete
+	restoreOwner := opts.RestoreOwner && os.Geteuid() == 0
+	for _, job := range slices.Concat(files, links) {
+		if !extracted[job.entry] {
+			continue
+		}
+		if err := applyMetadata(job, restoreOwner); err != nil {
+			if opts.StopOnError {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return len(dirs[i].target) > len(dirs[j].target)
+	})
+	for _, job := range dirs {
+		if err := applyMetadata(job, restoreOwner); err != nil {
+			if opts.StopOnError {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// extractFiles writes files and symlinks using a pool of workers and
+// returns the errors in the order they happened. Entries that were written
+// in full are recorded in extracted.
+func (r *Reader) extractFiles(ctx context.Context, dir string, jobs []extractJob, opts ExtractOptions, extracted map[*CentralDirectoryHeader]bool) []error {
+	workers := max(opts.Workers, 1)
+
+	var mu sync.Mutex
+	var errs []error
+	var stopped atomic.Bool
+
+	queue := make(chan extractJob)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				if stopped.Load() || ctx.Err() != nil {
+					continue
+				}
+
+				if opts.Observer != nil {
+					opts.Observer.EntryStart(job.entry.Filename, int64(job.entry.UncompressedSize))
+				}
+				var err error
+				if job.entry.Mode()&os.ModeSymlink != 0 {
+					err = r.extractSymlink(ctx, dir, job.target, job.entry, opts)
+				} else {
+					err = r.extractFile(ctx, job.target, job.entry, opts)
+				}
+				if opts.Observer != nil {
+					opts.Observer.EntryDone(job.entry.Filename, err)
+				}
+				if err == nil {
+					mu.Lock()
+					extracted[job.entry] = true
+					mu.Unlock()
+					continue
+				}
+
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", job.entry.Filename, err))
+				mu.Unlock()
+				if opts.StopOnError {
+					stopped.Store(true)
+				}
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		if stopped.Load() || ctx.Err() != nil {
+			break
+		}
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	return errs
+}
+
+// applyMetadata sets the owner, if restoreOwner is set, and the
+// permissions and modification time of an extracted file or directory.
+// Symlinks only get their owner, since their mode and times cannot
+// portably be set.
+func applyMetadata(job extractJob, restoreOwner bool) error {
+	if restoreOwner {
+		if uid, gid, ok := job.entry.Owner(); ok {
+			if err := os.Lchown(job.target, uid, gid); err != nil {
+				return err
+			}
+		}
+	}
+	if job.entry.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	if err := os.Chmod(job.target, job.entry.Mode().Perm()); err != nil {
+		return err
+	}
+	modified := job.entry.Modified()
+	return os.Chtimes(job.target, modified, modified)
+}
+
+// extractFile writes a regular file entry to target. A partially written
+// file is removed if the data cannot be read or ctx is done.
+func (r *Reader) extractFile(ctx context.Context, target string, entry *CentralDirectoryHeader, opts ExtractOptions) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if opts.Overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	raw, err := r.OpenRaw(entry)
+	if err != nil {
+		return err
+	}
+	raw = &contextReader{ctx, raw}
+	obs := opts.Observer
+	if obs != nil {
+		raw = &progressReader{r: raw, report: func(n int64) { obs.Progress(entry.Filename, n, 0) }}
+	}
+	rc, err := decompress(entry, raw, r.LocalHeaderOffset(entry))
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// An existing symlink is replaced rather than written through
+	if opts.Overwrite {
+		if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if err := os.Remove(target); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Permissions are applied once every file is written
+	out, err := os.OpenFile(target, flags, 0600)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = out
+	if obs != nil {
+		w = &progressWriter{w: out, report: func(n int64) { obs.Progress(entry.Filename, 0, n) }}
+	}
+	_, err = io.Copy(w, rc)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+	return nil
+}
+
+// extractSymlink creates a symbolic link whose target is the entry data.
+// Links pointing outside dir are rejected.
+func (r *Reader) extractSymlink(ctx context.Context, dir, target string, entry *CentralDirectoryHeader, opts ExtractOptions) error {
+	rc, err := r.OpenContext(ctx, entry)
+	if err != nil {
+		return err
+	}
+	link, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+
+	linkTarget := string(link)
+	if filepath.IsAbs(linkTarget) {
+		return fmt.Errorf("%w: symlink %s -> %s", ErrInsecurePath, entry.Filename, linkTarget)
+	}
+	resolved := filepath.Join(filepath.Dir(target), linkTarget)
+	if rel, err := filepath.Rel(dir, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%w: symlink %s -> %s", ErrInsecurePath, entry.Filename, linkTarget)
+	}
+	// The check above is only sound if the link's directory is where its
+	// name says it is
+	if err := checkSymlinks(dir, filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	if opts.Overwrite {
+		if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Symlink(linkTarget, target)
+}
+
+// safeJoin joins an entry name onto dir, refusing names that would end up
+// outside it.
+func safeJoin(dir, name string) (string, error) {
+	if name == "" || strings.Contains(name, `\`) || path.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: %q", ErrInsecurePath, name)
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %q", ErrInsecurePath, name)
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(cleaned)), nil
+}
+
+// insideSymlink returns an error if name lies below one of the symlink
+// entries, whose names are given cleaned. Writing it would follow the link
+// once it exists.
+func insideSymlink(name string, symlinks map[string]bool) error {
+	for parent := path.Dir(path.Clean(name)); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		if symlinks[parent] {
+			return fmt.Errorf("%w: %q is inside symlink %q", ErrInsecurePath, name, parent)
+		}
+	}
+	return nil
+}
+
+// checkSymlinks returns an error if p, or any directory between dir and p,
+// is a symlink on disk. Paths that do not exist yet are fine.
+func checkSymlinks(dir, p string) error {
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == "." {
+		return err
+	}
+	current := dir
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s is a symlink", ErrInsecurePath, current)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package zip
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExtractAll(t *testing.T) {
+	modified := time.Date(2023, 6, 15, 8, 30, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFileWith("bin/", []byte{}, FileOptions{Modified: modified})
+	zw.AddFileWith("bin/run.sh", []byte("#!/bin/sh\necho hi\n"), FileOptions{Modified: modified, Mode: 0755})
+	zw.AddFileWith("docs/readme.txt", bytes.Repeat([]byte("read me "), 100), FileOptions{Modified: modified, Method: Deflate})
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := r.ExtractAll(dir, ExtractOptions{}); err != nil {
+		t.Fatalf("ExtractAll failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "bin", "run.sh"))
+	if err != nil {
+		t.Fatalf("Extracted file missing: %v", err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("Expected mode 0755, got %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("Expected modification time %v, got %v", modified, info.ModTime())
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "docs", "readme.txt"))
+	if err != nil {
+		t.Fatalf("Extracted file missing: %v", err)
+	}
+	if !bytes.Equal(content, bytes.Repeat([]byte("read me "), 100)) {
+		t.Error("Content mismatch for docs/readme.txt")
+	}
+
+	// Extracting again must not silently overwrite
+	if err := r.ExtractAll(dir, ExtractOptions{}); err == nil {
+		t.Error("Expected an error extracting over existing files")
+	}
+	if err := r.ExtractAll(dir, ExtractOptions{Overwrite: true}); err != nil {
+		t.Errorf("ExtractAll with Overwrite failed: %v", err)
+	}
+}
+
+func TestSafeJoin(t *testing.T) {
+	valid := []string{"a.txt", "dir/b.txt", "dir/../c.txt", "./d.txt"}
+	for _, name := range valid {
+		if _, err := safeJoin("out", name); err != nil {
+			t.Errorf("Expected %q to be accepted, got %v", name, err)
+		}
+	}
+
+	insecure := []string{"", "../evil", "dir/../../evil", "/etc/passwd", `..\evil`, ".."}
+	for _, name := range insecure {
+		if _, err := safeJoin("out", name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestExtractAllParallel(t *testing.T) {
+	modified := time.Date(2022, 2, 2, 2, 2, 2, 0, time.Local)
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFileWith("tree/", []byte{}, FileOptions{Modified: modified, Mode: 0700 | os.ModeDir})
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("tree/dir%d/file%03d.txt", i%7, i)
+		zw.AddFileWith(name, bytes.Repeat([]byte(name), 50), FileOptions{Modified: modified, Method: Deflate})
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	if err := r.ExtractAll(dir, ExtractOptions{Workers: 8}); err != nil {
+		t.Fatalf("ExtractAll failed: %v", err)
+	}
+
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("tree/dir%d/file%03d.txt", i%7, i)
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatalf("Missing %s: %v", name, err)
+		}
+		if !bytes.Equal(content, bytes.Repeat([]byte(name), 50)) {
+			t.Errorf("Content mismatch for %s", name)
+		}
+	}
+
+	// Directory metadata is applied after its contents are written
+	info, err := os.Stat(filepath.Join(dir, "tree"))
+	if err != nil {
+		t.Fatalf("Missing tree/: %v", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("Expected tree/ to have mode 0700, got %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(modified) {
+		t.Errorf("Expected tree/ to be modified at %v, got %v", modified, info.ModTime())
+	}
+}
+
+func TestExtractAllAggregatesErrors(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("good.txt", []byte("good"))
+	zw.AddFile("../evil1.txt", []byte("evil"))
+	zw.AddFile("/evil2.txt", []byte("evil"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	err = r.ExtractAll(dir, ExtractOptions{Workers: 4})
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("Expected two joined errors, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "good.txt")); err != nil {
+		t.Errorf("Expected good.txt to be extracted despite the failures: %v", err)
+	}
+
+	err = r.ExtractAll(t.TempDir(), ExtractOptions{StopOnError: true})
+	if _, ok := err.(interface{ Unwrap() []error }); ok || err == nil {
+		t.Errorf("Expected a single error with StopOnError, got %v", err)
+	}
+}
+
+func TestExtractAllSymlinkChain(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFileWith("d/", nil, FileOptions{})
+	zw.AddFileWith("d/l", []byte(".."), FileOptions{Mode: 0777 | os.ModeSymlink})
+	zw.AddFileWith("d/l/m", []byte(".."), FileOptions{Mode: 0777 | os.ModeSymlink})
+	zw.AddFileWith("m/evil", []byte("pwned"), FileOptions{})
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	for _, overwrite := range []bool{false, true} {
+		parent := t.TempDir()
+		dir := filepath.Join(parent, "out")
+		err := r.ExtractAll(dir, ExtractOptions{Overwrite: overwrite, Workers: 1})
+		if !errors.Is(err, ErrInsecurePath) {
+			t.Errorf("Overwrite %v: expected ErrInsecurePath, got %v", overwrite, err)
+		}
+		if _, err := os.Lstat(filepath.Join(parent, "evil")); err == nil {
+			t.Errorf("Overwrite %v: file written outside the extraction directory", overwrite)
+		}
+		if info, err := os.Lstat(filepath.Join(dir, "m")); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			t.Errorf("Overwrite %v: symlink created through another symlink", overwrite)
+		}
+	}
+
+	// A symlink already on disk is not followed either
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("..", filepath.Join(dir, "m")); err != nil {
+		t.Fatal(err)
+	}
+	err = r.ExtractAll(dir, ExtractOptions{Overwrite: true, Filter: func(entry *CentralDirectoryHeader) bool {
+		return entry.Filename == "m/evil"
+	}})
+	if !errors.Is(err, ErrInsecurePath) {
+		t.Errorf("Expected ErrInsecurePath through an existing symlink, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(parent, "evil")); err == nil {
+		t.Error("File written through an existing symlink")
+	}
+}
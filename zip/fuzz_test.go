@@ -0,0 +1,178 @@
+package zip
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// addSeedArchives seeds f with archives produced by the writer in the
+// configurations the writer tests cover.
+func addSeedArchives(f *testing.F) {
+	modified := time.Date(2023, 6, 15, 8, 30, 0, 0, time.UTC)
+
+	seeds := []func(zw *ZipWriter){
+		func(zw *ZipWriter) {},
+		func(zw *ZipWriter) {
+			zw.AddFile("hello.txt", []byte("Hello, World!"))
+		},
+		func(zw *ZipWriter) {
+			zw.AddFile("empty.txt", []byte{})
+			zw.AddFileWith("big.txt", bytes.Repeat([]byte("compress me "), 1000), FileOptions{Method: Deflate})
+		},
+		func(zw *ZipWriter) {
+			zw.AddFileWith("bin/", []byte{}, FileOptions{Modified: modified})
+			zw.AddFileWith("bin/run.sh", []byte("#!/bin/sh\n"), FileOptions{Modified: modified, Mode: 0755})
+			zw.AddFile("héllo.txt", []byte("French"))
+			zw.AddFile("こんにちは.txt", []byte("Japanese"))
+		},
+		func(zw *ZipWriter) {
+			zw.SetPrefixBytes([]byte("#!/bin/sh\nexit 0\n"), RelativeOffsets)
+			zw.AddFile("payload.txt", []byte("installer payload"))
+		},
+		func(zw *ZipWriter) {
+			zw.SetPrefixBytes([]byte("#!/bin/sh\nexit 0\n"), AbsoluteOffsets)
+			zw.AddFile("payload.txt", []byte("installer payload"))
+		},
+	}
+	for _, fill := range seeds {
+		var buf bytes.Buffer
+		zw := NewZipWriter(&buf)
+		fill(zw)
+		if err := zw.Close(); err != nil {
+			f.Fatalf("Close failed: %v", err)
+		}
+		f.Add(buf.Bytes())
+	}
+}
+
+// signatureOffsets returns every offset in data where sig occurs.
+func signatureOffsets(data []byte, sig uint32) []int64 {
+	pattern := []byte{byte(sig), byte(sig >> 8), byte(sig >> 16), byte(sig >> 24)}
+	var offsets []int64
+	for i := 0; ; {
+		j := bytes.Index(data[i:], pattern)
+		if j < 0 {
+			return offsets
+		}
+		offsets = append(offsets, int64(i+j))
+		i += j + 1
+	}
+}
+
+func FuzzParseEOCD(f *testing.F) {
+	addSeedArchives(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		size := int64(len(data))
+
+		offset, err := findEOCD(r, size)
+		if err != nil {
+			return
+		}
+		if offset < 0 || offset+EOCDMinSize > size {
+			t.Fatalf("findEOCD returned offset %d for %d bytes", offset, size)
+		}
+		eocd, err := parseEOCD(r, size, offset)
+		if err != nil {
+			t.Fatalf("parseEOCD failed on the record findEOCD found: %v", err)
+		}
+		if offset+EOCDMinSize+int64(eocd.CommentLength) > size {
+			t.Fatalf("EOCD comment runs past the end of the file")
+		}
+	})
+}
+
+func FuzzReadCentralDirectory(f *testing.F) {
+	addSeedArchives(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		size := int64(len(data))
+
+		for _, offset := range signatureOffsets(data, CentralDirectorySignature) {
+			_, next, err := readCentralDirectoryEntry(r, size, offset)
+			if err == nil && next > size {
+				t.Fatalf("entry at %d ends at %d, past the end of the file", offset, next)
+			}
+		}
+
+		// Everything built on the central directory must cope too
+		zr, err := NewReader(r, size)
+		if err != nil {
+			return
+		}
+		for _, entry := range zr.Files {
+			entry.Mode()
+			entry.Modified()
+			if rc, err := zr.Open(entry); err == nil {
+				io.Copy(io.Discard, rc)
+				rc.Close()
+			}
+		}
+		zr.Records()
+		zr.Layout()
+	})
+}
+
+func FuzzReadLocalHeader(f *testing.F) {
+	addSeedArchives(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		r := bytes.NewReader(data)
+		size := int64(len(data))
+
+		for _, offset := range signatureOffsets(data, LocalFileHeaderSignature) {
+			_, dataOffset, err := readLocalFileHeader(r, size, offset)
+			if err == nil && dataOffset > size {
+				t.Fatalf("header at %d ends at %d, past the end of the file", offset, dataOffset)
+			}
+		}
+		for _, offset := range signatureOffsets(data, DataDescriptorSignature) {
+			readDataDescriptor(r, size, offset)
+		}
+	})
+}
+
+// boundedReaderAt fails the test if anything past size is read, as a
+// Reader over a section of a larger file must never do.
+type boundedReaderAt struct {
+	t    *testing.T
+	r    io.ReaderAt
+	size int64
+}
+
+func (b *boundedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(p)) > b.size {
+		b.t.Fatalf("read of %d bytes at %d runs past the archive size %d", len(p), off, b.size)
+	}
+	return b.r.ReadAt(p, off)
+}
+
+func FuzzRecords(f *testing.F) {
+	addSeedArchives(f)
+	f.Add(zip64Archive(f, 44))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// The archive is followed by bytes that belong to something else
+		size := int64(len(data))
+		file := append(append([]byte{}, data...), bytes.Repeat([]byte{0xff}, 64)...)
+		zr, err := NewReader(&boundedReaderAt{t, bytes.NewReader(file), size}, size)
+		if err != nil {
+			return
+		}
+
+		records, _ := zr.Records()
+		for _, record := range records {
+			if record.Offset < 0 || record.Size < 0 || record.Offset+record.Size > size {
+				t.Fatalf("%v at %d of %d bytes lies outside the archive of %d bytes", record.Type, record.Offset, record.Size, size)
+			}
+		}
+		// Regions may run past the end, which Layout flags as suspicious,
+		// but never backwards
+		regions, _ := zr.Layout()
+		for _, region := range regions {
+			if region.Start < 0 || region.End < region.Start {
+				t.Fatalf("%v region has negative bounds %d-%d", region.Kind, region.Start, region.End)
+			}
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package zip
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether an entry name matches a glob pattern.
+// Patterns use path.Match syntax for each slash-separated element, plus
+// "**", which matches any number of elements including none. A pattern
+// with no slash is matched against the last element of the name, so
+// "*.map" matches map files in every directory. A trailing slash on
+// directory entries is ignored.
+func MatchGlob(pattern, name string) bool {
+	name = strings.TrimSuffix(name, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElements(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated "**" and try every possible split
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchElements(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ValidateGlob reports whether pattern is well formed.
+func ValidateGlob(pattern string) error {
+	for _, element := range strings.Split(pattern, "/") {
+		if _, err := path.Match(element, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NameFilter selects entries by name. An entry is selected if it matches
+// at least one Include pattern, or there are none, and no Exclude pattern.
+type NameFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// Match reports whether name is selected by the filter.
+func (f NameFilter) Match(name string) bool {
+	included := len(f.Include) == 0
+	for _, pattern := range f.Include {
+		if MatchGlob(pattern, name) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, pattern := range f.Exclude {
+		if MatchGlob(pattern, name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package zip
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"bin/**", "bin/tool", true},
+		{"bin/**", "bin/linux/amd64/tool", true},
+		{"bin/**", "bin/", true},
+		{"bin/**", "lib/tool", false},
+		{"*.map", "app.js.map", true},
+		{"*.map", "static/js/app.js.map", true},
+		{"*.map", "static/js/app.js", false},
+		{"static/*.js", "static/app.js", true},
+		{"static/*.js", "static/js/app.js", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "zip/internal/glob.go", true},
+		{"src/**/test/*.txt", "src/test/a.txt", true},
+		{"src/**/test/*.txt", "src/a/b/test/a.txt", true},
+		{"src/**/test/*.txt", "src/a/b/test/c/a.txt", false},
+		{"doc?/[a-c].md", "docs/b.md", true},
+		{"doc?/[a-c].md", "docs/d.md", false},
+		{"**", "anything/at/all", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNameFilter(t *testing.T) {
+	filter := NameFilter{
+		Include: []string{"bin/**", "etc/*.conf"},
+		Exclude: []string{"*.map", "bin/debug/**"},
+	}
+
+	tests := map[string]bool{
+		"bin/app":           true,
+		"bin/app.map":       false,
+		"bin/debug/app":     false,
+		"etc/app.conf":      true,
+		"etc/app.conf.bak":  false,
+		"share/doc/app.txt": false,
+	}
+	for name, want := range tests {
+		if got := filter.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+
+	if !(NameFilter{}).Match("anything") {
+		t.Error("An empty filter should select everything")
+	}
+	if ValidateGlob("bin/[") == nil {
+		t.Error("Expected an error for a malformed pattern")
+	}
+}
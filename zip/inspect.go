@@ -0,0 +1,227 @@
+package zip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// RecordType identifies a structure within a ZIP archive.
+type RecordType int
+
+const (
+	RecordLocalHeader RecordType = iota
+	RecordDataDescriptor
+	RecordCentralDirectory
+	RecordZip64EOCD
+	RecordZip64Locator
+	RecordEOCD
+)
+
+func (t RecordType) String() string {
+	switch t {
+	case RecordLocalHeader:
+		return "local file header"
+	case RecordDataDescriptor:
+		return "data descriptor"
+	case RecordCentralDirectory:
+		return "central directory header"
+	case RecordZip64EOCD:
+		return "zip64 end of central directory"
+	case RecordZip64Locator:
+		return "zip64 end of central directory locator"
+	case RecordEOCD:
+		return "end of central directory"
+	default:
+		return fmt.Sprintf("record type %d", int(t))
+	}
+}
+
+// Record is one structure found in an archive.
+type Record struct {
+	Type RecordType
+	// Offset is the absolute file offset of the record's signature.
+	Offset int64
+	// Size is the length of the record including its variable-length
+	// fields, but not the file data that follows a local header.
+	Size int64
+	// Header is the decoded record: a *LocalFileHeader, *DataDescriptor,
+	// *CentralDirectoryHeader, *Zip64EndOfCentralDirectory,
+	// *Zip64EndOfCentralDirectoryLocator or *EndOfCentralDirectory.
+	Header any
+	// Entry is the central directory entry a local header or data
+	// descriptor belongs to.
+	Entry *CentralDirectoryHeader
+}
+
+// Records walks the archive and returns every record in file order: the
+// local header and data descriptor of each entry, the central directory
+// headers, any ZIP64 records and the EOCD. If a record cannot be read, the
+// records found so far are returned along with the error.
+func (r *Reader) Records() ([]Record, error) {
+	var records []Record
+	var firstErr error
+
+	for _, entry := range r.Files {
+		offset := r.LocalHeaderOffset(entry)
+		lh, dataOffset, err := readLocalFileHeader(r.r, r.size, offset)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = withEntry(err, entry)
+			}
+			continue
+		}
+		records = append(records, Record{
+			Type:   RecordLocalHeader,
+			Offset: offset,
+			Size:   dataOffset - offset,
+			Header: lh,
+			Entry:  entry,
+		})
+
+		if lh.Flags&0x0008 != 0 {
+			ddOffset := dataOffset + int64(entry.CompressedSize)
+			dd, size, err := readDataDescriptor(r.r, r.size, ddOffset)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = withEntry(err, entry)
+				}
+				continue
+			}
+			records = append(records, Record{
+				Type:   RecordDataDescriptor,
+				Offset: ddOffset,
+				Size:   size,
+				Header: dd,
+				Entry:  entry,
+			})
+		}
+	}
+
+	offset := r.baseOffset + int64(r.EOCD.CentralDirOffset)
+	for _, entry := range r.Files {
+		next := offset + 46 + int64(entry.FilenameLength) + int64(entry.ExtraFieldLength) + int64(entry.CommentLength)
+		records = append(records, Record{
+			Type:   RecordCentralDirectory,
+			Offset: offset,
+			Size:   next - offset,
+			Header: entry,
+		})
+		offset = next
+	}
+
+	zip64Records, err := r.zip64Records()
+	if err != nil && firstErr == nil {
+		firstErr = err
+	}
+	records = append(records, zip64Records...)
+
+	records = append(records, Record{
+		Type:   RecordEOCD,
+		Offset: r.eocdOffset,
+		Size:   EOCDMinSize + int64(r.EOCD.CommentLength),
+		Header: r.EOCD,
+	})
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Offset < records[j].Offset
+	})
+	return records, firstErr
+}
+
+// readDataDescriptor reads the data descriptor at offset in an archive of
+// size bytes and returns it with its size, which depends on whether the
+// optional signature is there.
+func readDataDescriptor(r io.ReaderAt, size, offset int64) (*DataDescriptor, int64, error) {
+	if err := checkOffset(RecordDataDescriptor, size, offset); err != nil {
+		return nil, 0, err
+	}
+	buf := make([]byte, 16)
+	n, err := io.ReadAtLeast(recordReader(r, size, offset), buf, 12)
+	if err != nil {
+		return nil, 0, truncated(RecordDataDescriptor, offset, err)
+	}
+
+	dd := &DataDescriptor{}
+	fields := buf[:12]
+	if binary.LittleEndian.Uint32(buf) == DataDescriptorSignature {
+		if n < 16 {
+			return nil, 0, truncated(RecordDataDescriptor, offset, io.ErrUnexpectedEOF)
+		}
+		dd.HasSignature = true
+		fields = buf[4:16]
+	}
+
+	dd.CRC32 = binary.LittleEndian.Uint32(fields[0:4])
+	dd.CompressedSize = binary.LittleEndian.Uint32(fields[4:8])
+	dd.UncompressedSize = binary.LittleEndian.Uint32(fields[8:12])
+
+	recordSize := int64(12)
+	if dd.HasSignature {
+		recordSize = 16
+	}
+	return dd, recordSize, nil
+}
+
+// zip64Records returns the ZIP64 locator that sits immediately before the
+// EOCD, and the ZIP64 EOCD record it points to, if the archive has them.
+func (r *Reader) zip64Records() ([]Record, error) {
+	locatorOffset := r.eocdOffset - Zip64EOCDLocatorSize
+	if locatorOffset < 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, Zip64EOCDLocatorSize)
+	if _, err := io.ReadFull(recordReader(r.r, r.size, locatorOffset), buf); err != nil {
+		return nil, nil
+	}
+	if binary.LittleEndian.Uint32(buf) != Zip64EndOfCentralDirectoryLocatorSignature {
+		return nil, nil
+	}
+
+	locator := &Zip64EndOfCentralDirectoryLocator{
+		DiskWithZip64EOCD: binary.LittleEndian.Uint32(buf[4:8]),
+		Zip64EOCDOffset:   binary.LittleEndian.Uint64(buf[8:16]),
+		TotalDisks:        binary.LittleEndian.Uint32(buf[16:20]),
+	}
+	records := []Record{{
+		Type:   RecordZip64Locator,
+		Offset: locatorOffset,
+		Size:   Zip64EOCDLocatorSize,
+		Header: locator,
+	}}
+
+	// The record must end before the locator, which also keeps its
+	// offset and size from overflowing
+	eocdOffset := r.baseOffset + int64(locator.Zip64EOCDOffset)
+	if locator.Zip64EOCDOffset > uint64(locatorOffset) || eocdOffset > locatorOffset-56 {
+		return records, &FormatError{Record: RecordZip64EOCD, Offset: -1, Field: "offset",
+			Expected: fmt.Sprintf("at most %d", locatorOffset-56), Actual: locator.Zip64EOCDOffset}
+	}
+	fixed := make([]byte, 56)
+	if _, err := io.ReadFull(recordReader(r.r, r.size, eocdOffset), fixed); err != nil {
+		return records, truncated(RecordZip64EOCD, eocdOffset, err)
+	}
+	if sig := binary.LittleEndian.Uint32(fixed); sig != Zip64EndOfCentralDirectorySignature {
+		return records, &FormatError{Record: RecordZip64EOCD, Offset: eocdOffset, Field: "signature",
+			Expected: uint32(Zip64EndOfCentralDirectorySignature), Actual: sig}
+	}
+
+	eocd := &Zip64EndOfCentralDirectory{}
+	if err := binary.Read(bytes.NewReader(fixed[4:]), binary.LittleEndian, eocd); err != nil {
+		return records, err
+	}
+	if maxSize := locatorOffset - eocdOffset - 12; eocd.RecordSize < 44 || eocd.RecordSize > uint64(maxSize) {
+		return records, &FormatError{Record: RecordZip64EOCD, Offset: eocdOffset, Field: "record size",
+			Expected: fmt.Sprintf("between 44 and %d", maxSize), Actual: eocd.RecordSize}
+	}
+	records = append(records, Record{
+		Type:   RecordZip64EOCD,
+		Offset: eocdOffset,
+		Size:   12 + int64(eocd.RecordSize),
+		Header: eocd,
+	})
+	return records, nil
+}
@@ -0,0 +1,184 @@
+package zip
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RegionKind classifies a byte range of an archive.
+type RegionKind int
+
+const (
+	RegionPrefix RegionKind = iota
+	RegionLocalHeader
+	RegionFileData
+	RegionDataDescriptor
+	RegionCentralDirectory
+	RegionZip64EOCD
+	RegionZip64Locator
+	RegionEOCD
+	RegionComment
+	RegionGap
+	RegionOverlap
+	RegionTrailing
+)
+
+func (k RegionKind) String() string {
+	switch k {
+	case RegionPrefix:
+		return "prepended data"
+	case RegionLocalHeader:
+		return "local file header"
+	case RegionFileData:
+		return "file data"
+	case RegionDataDescriptor:
+		return "data descriptor"
+	case RegionCentralDirectory:
+		return "central directory header"
+	case RegionZip64EOCD:
+		return "zip64 end of central directory"
+	case RegionZip64Locator:
+		return "zip64 end of central directory locator"
+	case RegionEOCD:
+		return "end of central directory"
+	case RegionComment:
+		return "archive comment"
+	case RegionGap:
+		return "gap"
+	case RegionOverlap:
+		return "overlap"
+	case RegionTrailing:
+		return "trailing data"
+	default:
+		return fmt.Sprintf("region kind %d", int(k))
+	}
+}
+
+// Region is the byte range [Start, End) of an archive.
+type Region struct {
+	Kind  RegionKind
+	Start int64
+	End   int64
+	// Name is the entry the region belongs to, if any.
+	Name string
+	// Suspicious marks bytes that no record accounts for, or that more
+	// than one record claims. They can hide payloads or make the file a
+	// polyglot that other tools interpret differently.
+	Suspicious bool
+	// Note explains why a region is suspicious.
+	Note string
+}
+
+// Size returns the length of the region in bytes.
+func (g Region) Size() int64 {
+	return g.End - g.Start
+}
+
+// Layout accounts for every byte of the archive: prepended data, each
+// local header, its file data and data descriptor, the central directory,
+// the ZIP64 records, the EOCD and its comment. Bytes between records are
+// reported as gaps, bytes claimed twice as overlaps and bytes after the
+// EOCD comment as trailing data, all marked suspicious, as is prepended
+// data. The regions are returned in file order. If some records cannot be
+// read, the layout of the rest is returned along with the error.
+func (r *Reader) Layout() ([]Region, error) {
+	fileSize := r.size
+
+	// A damaged local header leaves its bytes unaccounted for, which is
+	// worth reporting along with the error
+	records, recordsErr := r.Records()
+
+	var regions []Region
+	if r.prefixLength > 0 {
+		regions = append(regions, Region{
+			Kind:       RegionPrefix,
+			Start:      0,
+			End:        r.prefixLength,
+			Suspicious: true,
+			Note:       "data before the first record (self-extractor stub or polyglot content)",
+		})
+	}
+
+	for _, record := range records {
+		name := ""
+		if record.Entry != nil {
+			name = record.Entry.Filename
+		}
+
+		switch record.Type {
+		case RecordLocalHeader:
+			dataStart := record.Offset + record.Size
+			regions = append(regions,
+				Region{Kind: RegionLocalHeader, Start: record.Offset, End: dataStart, Name: name},
+				Region{Kind: RegionFileData, Start: dataStart, End: dataStart + int64(record.Entry.CompressedSize), Name: name},
+			)
+		case RecordDataDescriptor:
+			regions = append(regions, Region{Kind: RegionDataDescriptor, Start: record.Offset, End: record.Offset + record.Size, Name: name})
+		case RecordCentralDirectory:
+			entry := record.Header.(*CentralDirectoryHeader)
+			regions = append(regions, Region{Kind: RegionCentralDirectory, Start: record.Offset, End: record.Offset + record.Size, Name: entry.Filename})
+		case RecordZip64EOCD:
+			regions = append(regions, Region{Kind: RegionZip64EOCD, Start: record.Offset, End: record.Offset + record.Size})
+		case RecordZip64Locator:
+			regions = append(regions, Region{Kind: RegionZip64Locator, Start: record.Offset, End: record.Offset + record.Size})
+		case RecordEOCD:
+			commentStart := record.Offset + EOCDMinSize
+			regions = append(regions, Region{Kind: RegionEOCD, Start: record.Offset, End: commentStart})
+			if record.Size > EOCDMinSize {
+				regions = append(regions, Region{Kind: RegionComment, Start: commentStart, End: record.Offset + record.Size})
+			}
+		}
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].Start < regions[j].Start
+	})
+
+	// Sweep through the regions in order, filling in gaps and overlaps
+	var layout []Region
+	var pos int64
+	var last Region
+	for _, region := range regions {
+		if region.Start > pos {
+			layout = append(layout, Region{
+				Kind:       RegionGap,
+				Start:      pos,
+				End:        region.Start,
+				Suspicious: true,
+				Note:       "not accounted for by any record",
+			})
+		} else if region.Start < pos {
+			layout = append(layout, Region{
+				Kind:       RegionOverlap,
+				Start:      region.Start,
+				End:        min(pos, region.End),
+				Name:       region.Name,
+				Suspicious: true,
+				Note:       fmt.Sprintf("%s overlaps %s %s", region.Kind, last.Kind, last.Name),
+			})
+		}
+
+		if region.End > fileSize {
+			region.Suspicious = true
+			region.Note = fmt.Sprintf("extends %d bytes past the end of the file", region.End-fileSize)
+		}
+		layout = append(layout, region)
+
+		if region.End > pos {
+			pos = region.End
+			last = region
+		}
+	}
+
+	if pos < fileSize {
+		layout = append(layout, Region{
+			Kind:       RegionTrailing,
+			Start:      pos,
+			End:        fileSize,
+			Suspicious: true,
+			Note:       "data after the end of central directory record",
+		})
+	}
+
+	return layout, recordsErr
+}
@@ -0,0 +1,67 @@
+package zip
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLayout(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("a.txt", []byte("first"))
+	zw.AddFile("b.txt", []byte("second"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	layout, err := r.Layout()
+	if err != nil {
+		t.Fatalf("Layout failed: %v", err)
+	}
+
+	var end int64
+	for _, region := range layout {
+		if region.Suspicious {
+			t.Errorf("Unexpected suspicious region in clean archive: %+v", region)
+		}
+		if region.Start != end {
+			t.Errorf("Region %s starts at %d, expected %d", region.Kind, region.Start, end)
+		}
+		end = region.End
+	}
+	if end != int64(buf.Len()) {
+		t.Errorf("Layout ends at %d, archive is %d bytes", end, buf.Len())
+	}
+}
+
+func TestLayoutHiddenData(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.SetPrefixBytes([]byte("MZ-polyglot"), RelativeOffsets)
+	zw.AddFile("a.txt", []byte("first"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	buf.WriteString("hidden payload")
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	layout, err := r.Layout()
+	if err != nil {
+		t.Fatalf("Layout failed: %v", err)
+	}
+
+	first, last := layout[0], layout[len(layout)-1]
+	if first.Kind != RegionPrefix || !first.Suspicious || first.Size() != int64(len("MZ-polyglot")) {
+		t.Errorf("Expected a suspicious prefix region, got %+v", first)
+	}
+	if last.Kind != RegionTrailing || !last.Suspicious || last.Size() != int64(len("hidden payload")) {
+		t.Errorf("Expected a suspicious trailing region, got %+v", last)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package zip
+
+import "io/fs"
+
+// fileOwner returns nil, as files have no Unix owner on this platform.
+func fileOwner(info fs.FileInfo) *UnixOwner {
+	return nil
+}
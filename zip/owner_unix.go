@@ -0,0 +1,17 @@
+//go:build unix
+
+package zip
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the numeric owner and group of a file on disk.
+func fileOwner(info fs.FileInfo) *UnixOwner {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return &UnixOwner{UID: int(stat.Uid), GID: int(stat.Gid)}
+}
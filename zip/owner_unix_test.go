@@ -0,0 +1,42 @@
+//go:build unix
+
+package zip
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestExtractRestoreOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("restoring ownership needs root")
+	}
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFileWith("owned.txt", []byte("mine"), FileOptions{Owner: &UnixOwner{UID: 1234, GID: 5678}})
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	dir := t.TempDir()
+	if err := r.ExtractAll(dir, ExtractOptions{RestoreOwner: true}); err != nil {
+		t.Fatalf("ExtractAll failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "owned.txt"))
+	if err != nil {
+		t.Fatalf("Extracted file missing: %v", err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	if stat.Uid != 1234 || stat.Gid != 5678 {
+		t.Errorf("Expected owner 1234:5678, got %d:%d", stat.Uid, stat.Gid)
+	}
+}
@@ -0,0 +1,72 @@
+package zip
+
+import (
+	"context"
+	"io"
+)
+
+// Observer receives progress reports from long-running operations such as
+// ZipWriter.AddPaths and Reader.ExtractAll. During parallel extraction its
+// methods are called from several goroutines at once.
+type Observer interface {
+	// Start is called once before the first entry with the number of
+	// entries and their total uncompressed size, when those are known up
+	// front.
+	Start(entries int, totalBytes int64)
+	// EntryStart is called before an entry is processed.
+	EntryStart(name string, uncompressedSize int64)
+	// Progress reports the compressed and uncompressed bytes processed for
+	// an entry since the previous call.
+	Progress(name string, compressed, uncompressed int64)
+	// EntryDone is called once an entry is finished, with the error if it
+	// failed.
+	EntryDone(name string, err error)
+}
+
+// progressChunk is how much data is written between progress reports.
+const progressChunk = 256 * 1024
+
+// writeChunks writes data to w in chunks, calling report after each. It
+// stops early once ctx is done.
+func writeChunks(ctx context.Context, w io.Writer, data []byte, report func(n int64)) error {
+	for len(data) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		chunk := data[:min(len(data), progressChunk)]
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+		report(int64(len(chunk)))
+		data = data[len(chunk):]
+	}
+	return nil
+}
+
+// progressReader calls report with the size of every read.
+type progressReader struct {
+	r      io.Reader
+	report func(n int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.report(int64(n))
+	}
+	return n, err
+}
+
+// progressWriter calls report with the size of every write.
+type progressWriter struct {
+	w      io.Writer
+	report func(n int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	if n > 0 {
+		p.report(int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,126 @@
+package zip
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// recordingObserver totals the progress reported for each entry.
+type recordingObserver struct {
+	mu           sync.Mutex
+	entries      int
+	totalBytes   int64
+	started      map[string]int64
+	compressed   map[string]int64
+	uncompressed map[string]int64
+	done         map[string]error
+}
+
+func newRecordingObserver() *recordingObserver {
+	return &recordingObserver{
+		started:      make(map[string]int64),
+		compressed:   make(map[string]int64),
+		uncompressed: make(map[string]int64),
+		done:         make(map[string]error),
+	}
+}
+
+func (o *recordingObserver) Start(entries int, totalBytes int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries, o.totalBytes = entries, totalBytes
+}
+
+func (o *recordingObserver) EntryStart(name string, uncompressedSize int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started[name] = uncompressedSize
+}
+
+func (o *recordingObserver) Progress(name string, compressed, uncompressed int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.compressed[name] += compressed
+	o.uncompressed[name] += uncompressed
+}
+
+func (o *recordingObserver) EntryDone(name string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.done[name] = err
+}
+
+func TestWriterObserver(t *testing.T) {
+	big := bytes.Repeat([]byte("compress me "), 100000)
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	obs := newRecordingObserver()
+	zw.SetObserver(obs)
+	zw.AddFileWith("big.txt", big, FileOptions{Method: Deflate})
+	zw.AddFile("small.txt", []byte("tiny"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	for _, entry := range r.Files {
+		name := entry.Filename
+		if obs.started[name] != int64(entry.UncompressedSize) {
+			t.Errorf("%s: EntryStart reported %d bytes, expected %d", name, obs.started[name], entry.UncompressedSize)
+		}
+		if obs.uncompressed[name] != int64(entry.UncompressedSize) {
+			t.Errorf("%s: reported %d uncompressed bytes, expected %d", name, obs.uncompressed[name], entry.UncompressedSize)
+		}
+		if obs.compressed[name] != int64(entry.CompressedSize) {
+			t.Errorf("%s: reported %d compressed bytes, expected %d", name, obs.compressed[name], entry.CompressedSize)
+		}
+		if err, ok := obs.done[name]; !ok || err != nil {
+			t.Errorf("%s: expected EntryDone without error, got %v (called: %v)", name, err, ok)
+		}
+	}
+}
+
+func TestExtractAllObserver(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("dir/", []byte{})
+	zw.AddFileWith("dir/a.txt", bytes.Repeat([]byte("aaaa"), 5000), FileOptions{Method: Deflate})
+	zw.AddFile("b.txt", []byte("bbbb"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	obs := newRecordingObserver()
+	if err := r.ExtractAll(t.TempDir(), ExtractOptions{Workers: 2, Observer: obs}); err != nil {
+		t.Fatalf("ExtractAll failed: %v", err)
+	}
+
+	if obs.entries != 2 || obs.totalBytes != 20004 {
+		t.Errorf("Expected Start(2, 20004), got Start(%d, %d)", obs.entries, obs.totalBytes)
+	}
+	if _, ok := obs.started["dir/"]; ok {
+		t.Error("Directories should not be reported")
+	}
+	for _, entry := range r.Files[1:] {
+		name := entry.Filename
+		if obs.uncompressed[name] != int64(entry.UncompressedSize) {
+			t.Errorf("%s: reported %d uncompressed bytes, expected %d", name, obs.uncompressed[name], entry.UncompressedSize)
+		}
+		if obs.compressed[name] != int64(entry.CompressedSize) {
+			t.Errorf("%s: reported %d compressed bytes, expected %d", name, obs.compressed[name], entry.CompressedSize)
+		}
+		if err, ok := obs.done[name]; !ok || err != nil {
+			t.Errorf("%s: expected EntryDone without error, got %v (called: %v)", name, err, ok)
+		}
+	}
+}
@@ -1,10 +1,22 @@
 package zip
 
+import "time"
+
+const (
+	EOCDMinSize                                = 22
+	LocalFileHeaderSignature                   = 0x04034b50
+	CentralDirectorySignature                  = 0x02014b50
+	EndOfCentralDirectorySignature             = 0x06054b50
+	DataDescriptorSignature                    = 0x08074b50
+	Zip64EndOfCentralDirectorySignature        = 0x06064b50
+	Zip64EndOfCentralDirectoryLocatorSignature = 0x07064b50
+	Zip64EOCDLocatorSize                       = 20
+)
+
+// Compression methods
 const (
-	EOCDMinSize                    = 22
-	LocalFileHeaderSignature       = 0x04034b50
-	CentralDirectorySignature      = 0x02014b50
-	EndOfCentralDirectorySignature = 0x06054b50
+	Store   uint16 = 0
+	Deflate uint16 = 8
 )
 
 type LocalFileHeader struct {
@@ -53,4 +65,43 @@ type CentralDirectoryHeader struct {
 	Filename           string
 	ExtraField         []byte
 	Comment            string
+	// RawFilename and RawComment hold the bytes stored in the archive.
+	// Filename and Comment are decoded from them: as UTF-8 when flag bit
+	// 11 is set, and otherwise with the reader's name decoder.
+	RawFilename []byte
+	RawComment  []byte
+
+	// location is the time zone DOSTime decodes in, or nil for local time.
+	location *time.Location
+	// decodedFilename and decodedComment are what Filename and Comment
+	// were decoded to, so AddRaw can tell whether they have been changed.
+	decodedFilename, decodedComment string
+}
+
+// DataDescriptor follows the file data when general purpose flag bit 3 is
+// set. The signature is optional in the spec, so HasSignature records
+// whether it was present.
+type DataDescriptor struct {
+	HasSignature     bool
+	CRC32            uint32
+	CompressedSize   uint32
+	UncompressedSize uint32
+}
+
+type Zip64EndOfCentralDirectory struct {
+	RecordSize       uint64
+	VersionMadeBy    uint16
+	VersionNeeded    uint16
+	DiskNumber       uint32
+	DiskWithCDStart  uint32
+	EntriesOnDisk    uint64
+	TotalEntries     uint64
+	CentralDirSize   uint64
+	CentralDirOffset uint64
+}
+
+type Zip64EndOfCentralDirectoryLocator struct {
+	DiskWithZip64EOCD uint32
+	Zip64EOCDOffset   uint64
+	TotalDisks        uint32
 }
@@ -0,0 +1,184 @@
+package zip
+
+import (
+	"context"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// UpdateOptions controls how Update decides which entries to rewrite.
+type UpdateOptions struct {
+	// CompareCRC also checks the CRC-32 of files whose size and
+	// modification time are unchanged, catching edits that keep both.
+	CompareCRC bool
+	// Sync drops entries under the given paths whose source file no
+	// longer exists, like Info-ZIP's -FS. Without it they are carried
+	// over, like -u.
+	Sync bool
+	// Method is the compression method used for new and changed files.
+	Method uint16
+}
+
+// UpdateResult lists the entry names Update handled, by outcome.
+type UpdateResult struct {
+	Unchanged []string
+	Updated   []string
+	Added     []string
+	Removed   []string
+}
+
+// sourceFile is a file or directory found on disk.
+type sourceFile struct {
+	path string
+	info fs.FileInfo
+}
+
+// Update writes a new archive to zw from the existing archive r and the
+// files and directories at paths. Entries are named as AddPaths names
+// them, so an archive made with AddPaths can be updated from the same
+// paths. Entries whose source file has the same size and modification time
+// (and CRC-32, if asked) are copied raw from r without being recompressed.
+// Changed files are compressed again, files not yet in the archive are
+// appended, and entries under paths whose source disappeared are either
+// kept or dropped depending on opts.Sync. Entries outside paths are always
+// kept.
+//
+// The caller still has to Close zw.
+func Update(zw *ZipWriter, r *Reader, paths []string, opts UpdateOptions) (*UpdateResult, error) {
+	return UpdateContext(context.Background(), zw, r, paths, opts)
+}
+
+// UpdateContext is like Update but stops once ctx is done. The archive is
+// then incomplete and must be discarded.
+func UpdateContext(ctx context.Context, zw *ZipWriter, r *Reader, paths []string, opts UpdateOptions) (*UpdateResult, error) {
+	names, list, err := walkPaths(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]sourceFile, len(names))
+	for i, name := range names {
+		sources[name] = list[i]
+	}
+
+	result := &UpdateResult{}
+	seen := make(map[string]bool)
+
+	for _, entry := range r.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		name := entry.Filename
+		seen[name] = true
+
+		src, ok := sources[name]
+		if !ok {
+			if opts.Sync && underPaths(name, paths) {
+				result.Removed = append(result.Removed, name)
+				continue
+			}
+			if err := zw.Copy(r, entry); err != nil {
+				return nil, err
+			}
+			result.Unchanged = append(result.Unchanged, name)
+			continue
+		}
+
+		unchanged, err := sourceUnchanged(entry, src, opts.CompareCRC)
+		if err != nil {
+			return nil, err
+		}
+		if unchanged {
+			if err := zw.Copy(r, entry); err != nil {
+				return nil, err
+			}
+			result.Unchanged = append(result.Unchanged, name)
+			continue
+		}
+
+		if err := addSource(ctx, zw, name, src, opts.Method); err != nil {
+			return nil, err
+		}
+		result.Updated = append(result.Updated, name)
+	}
+
+	// Anything left over is new
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		if err := addSource(ctx, zw, name, sources[name], opts.Method); err != nil {
+			return nil, err
+		}
+		result.Added = append(result.Added, name)
+	}
+
+	return result, nil
+}
+
+// underPaths reports whether the entry called name would come from one of
+// paths, so that its absence on disk means it was deleted.
+func underPaths(name string, paths []string) bool {
+	name = strings.TrimSuffix(name, "/")
+	for _, p := range paths {
+		prefix := EntryName(p)
+		if prefix == "" || name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceUnchanged reports whether src still matches entry.
+func sourceUnchanged(entry *CentralDirectoryHeader, src sourceFile, compareCRC bool) (bool, error) {
+	if src.info.IsDir() {
+		return true, nil
+	}
+	if int64(entry.UncompressedSize) != src.info.Size() {
+		return false, nil
+	}
+
+	// DOS times have two-second resolution, so compare in that form
+	modTime, modDate := timeToMSDos(src.info.ModTime())
+	if modTime != entry.LastModTime || modDate != entry.LastModDate {
+		return false, nil
+	}
+
+	if compareCRC {
+		data, err := os.ReadFile(src.path)
+		if err != nil {
+			return false, err
+		}
+		if crc32.ChecksumIEEE(data) != entry.CRC32 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func addSource(ctx context.Context, zw *ZipWriter, name string, src sourceFile, method uint16) error {
+	opts := FileOptions{
+		Modified: src.info.ModTime(),
+		Mode:     src.info.Mode(),
+		Method:   method,
+		Owner:    fileOwner(src.info),
+	}
+	if src.info.IsDir() {
+		return zw.AddFileContext(ctx, name, []byte{}, FileOptions{Modified: opts.Modified, Mode: opts.Mode, Owner: opts.Owner})
+	}
+
+	if zw.reproducible {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return zw.addPendingPath(name, src.path, src.info.Size(), opts)
+	}
+
+	data, err := os.ReadFile(src.path)
+	if err != nil {
+		return err
+	}
+	return zw.AddFileContext(ctx, name, data, opts)
+}
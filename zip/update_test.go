@@ -0,0 +1,127 @@
+package zip
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// chdir changes the working directory to dir for the rest of the test.
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir failed: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestUpdate(t *testing.T) {
+	chdir(t, t.TempDir())
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+
+	files := map[string]string{
+		"keep.txt":   "unchanged",
+		"change.txt": "old content",
+		"gone.txt":   "about to be deleted",
+	}
+	if err := os.Mkdir("src", 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join("src", name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatalf("Failed to set times on %s: %v", name, err)
+		}
+	}
+	os.WriteFile("outside.txt", []byte("not under src"), 0644)
+
+	// Build the initial archive the way "gozip create" does
+	var original bytes.Buffer
+	zw := NewZipWriter(&original)
+	if _, err := zw.AddPaths([]string{"src", "outside.txt"}, Deflate); err != nil {
+		t.Fatalf("AddPaths failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Change one file, remove one and add one
+	changed := filepath.Join("src", "change.txt")
+	os.WriteFile(changed, []byte("new content"), 0644)
+	os.Chtimes(changed, modified, modified) // same size and time, only the CRC differs
+	os.Remove(filepath.Join("src", "gone.txt"))
+	os.WriteFile(filepath.Join("src", "new.txt"), []byte("brand new"), 0644)
+	os.Remove("outside.txt")
+
+	r, err := NewReader(bytes.NewReader(original.Bytes()), int64(original.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	var updated bytes.Buffer
+	zw = NewZipWriter(&updated)
+	result, err := Update(zw, r, []string{"src"}, UpdateOptions{CompareCRC: true, Sync: true, Method: Deflate})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Entries outside the updated paths are kept even with Sync
+	if !slices.Equal(result.Unchanged, []string{"src/", "src/keep.txt", "outside.txt"}) {
+		t.Errorf("Unexpected unchanged entries: %v", result.Unchanged)
+	}
+	if !slices.Equal(result.Updated, []string{"src/change.txt"}) {
+		t.Errorf("Unexpected updated entries: %v", result.Updated)
+	}
+	if !slices.Equal(result.Removed, []string{"src/gone.txt"}) {
+		t.Errorf("Unexpected removed entries: %v", result.Removed)
+	}
+	if !slices.Equal(result.Added, []string{"src/new.txt"}) {
+		t.Errorf("Unexpected added entries: %v", result.Added)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(updated.Bytes()), int64(updated.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open updated ZIP: %v", err)
+	}
+
+	expected := map[string]string{
+		"src/":           "",
+		"src/keep.txt":   "unchanged",
+		"src/change.txt": "new content",
+		"src/new.txt":    "brand new",
+		"outside.txt":    "not under src",
+	}
+	if len(zipReader.File) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(zipReader.File))
+	}
+	for _, file := range zipReader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file.Name, err)
+		}
+		if string(content) != expected[file.Name] {
+			t.Errorf("Content mismatch for %s: %q", file.Name, content)
+		}
+	}
+}
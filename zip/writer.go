@@ -1,10 +1,20 @@
 package zip
 
 import (
+	"bytes"
+	"compress/flate"
+	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"hash/crc32"
 	"io"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 )
@@ -13,8 +23,70 @@ type ZipWriter struct {
 	w      io.Writer
 	files  []fileRecord
 	offset int64
+	// prefixed is set once data has been written in front of the archive.
+	prefixed bool
+	// In reproducible mode files are held back until Close so they can be
+	// written in sorted order with a fixed timestamp. Files added from disk
+	// are held back by path and only read then.
+	reproducible bool
+	fixedTime    time.Time
+	pending      []pendingFile
+	observer     Observer
+	ntfsTimes    bool
 }
 
+// FileOptions holds optional metadata for an entry added with AddFileWith.
+// Zero values select the defaults used by AddFile.
+type FileOptions struct {
+	// Modified is the entry's modification time. Defaults to time.Now().
+	Modified time.Time
+	// Mode holds the entry's permission bits and type. Defaults to 0644,
+	// or 0755 with os.ModeDir for names ending in a slash.
+	Mode os.FileMode
+	// Method is the compression method, Store or Deflate. Defaults to Store.
+	Method uint16
+	// Accessed and Created are written to the extended timestamp extra
+	// field along with Modified. They are left out when zero.
+	Accessed time.Time
+	Created  time.Time
+	// Owner, if set, is written to an Info-ZIP UID/GID extra field.
+	Owner *UnixOwner
+	// LegacyName, if set, is stored as the entry name in place of the
+	// UTF-8 name, for tools that only read names in a code page such as
+	// CP437. The UTF-8 name, which must be valid, goes in an Info-ZIP
+	// Unicode Path extra field unless Extra already holds one.
+	LegacyName []byte
+	// Extra lists extra fields to write to the entry's local and central
+	// directory headers, in order.
+	Extra []ExtraField
+	// NoTimestamp leaves out the extended timestamp extra field that is
+	// otherwise added with the exact times, so that only the MS-DOS date
+	// and time record Modified.
+	NoTimestamp bool
+}
+
+// pendingFile is a file held back by reproducible mode. Its data is either
+// in data or, for files added from disk, read from path on Close.
+type pendingFile struct {
+	name string
+	data []byte
+	path string
+	opts FileOptions
+}
+
+// OffsetMode selects how a ZipWriter records offsets when data has been
+// written in front of the archive with SetPrefix.
+type OffsetMode int
+
+const (
+	// AbsoluteOffsets records offsets from the start of the output, which
+	// is what unzip and most other tools expect from a self-extractor.
+	AbsoluteOffsets OffsetMode = iota
+	// RelativeOffsets records offsets from the start of the zip data, so
+	// the archive is unchanged apart from the bytes in front of it.
+	RelativeOffsets
+)
+
 type fileRecord struct {
 	name              string
 	compressedSize    uint32
@@ -27,7 +99,8 @@ type fileRecord struct {
 	versionMadeBy     uint16
 	versionNeeded     uint16
 	flags             uint16
-	extraField        []byte
+	extraField        []byte // in the local header
+	centralExtra      []byte // in the central directory header
 	comment           string
 	diskNumberStart   uint16
 	internalAttrs     uint16
@@ -42,130 +115,536 @@ func NewZipWriter(w io.Writer) *ZipWriter {
 	}
 }
 
+// SetPrefix copies prefix to the output ahead of the archive, for building
+// self-extracting executables or shell-script installers. It must be called
+// before any file is added. mode controls whether the offsets written to
+// the central directory count the prefix bytes.
+func (zw *ZipWriter) SetPrefix(prefix io.Reader, mode OffsetMode) error {
+	if zw.prefixed {
+		return errors.New("zip prefix already written")
+	}
+	if len(zw.files) > 0 || zw.offset != 0 {
+		return errors.New("zip prefix must be written before any file")
+	}
+
+	n, err := io.Copy(zw.w, prefix)
+	if err != nil {
+		return err
+	}
+	zw.prefixed = true
+
+	if mode == AbsoluteOffsets {
+		zw.offset = n
+	}
+	return nil
+}
+
+// SetPrefixBytes is like SetPrefix but takes the prefix as a byte slice.
+func (zw *ZipWriter) SetPrefixBytes(prefix []byte, mode OffsetMode) error {
+	return zw.SetPrefix(bytes.NewReader(prefix), mode)
+}
+
+// SetReproducible switches the writer into reproducible mode, where two
+// runs over the same inputs produce identical bytes:
+//   - every entry is stamped with the time in SOURCE_DATE_EPOCH, or with
+//     1980-01-01 00:00:00 UTC (the earliest DOS time) if it is unset
+//   - entries are written in name order when the writer is closed
+//   - permissions are normalized to 0644 for files and 0755 for directories
+//   - no host-dependent extra fields are written
+//
+// It must be called before any file is added.
+func (zw *ZipWriter) SetReproducible() error {
+	if len(zw.files) > 0 || len(zw.pending) > 0 {
+		return errors.New("zip reproducible mode must be set before any file")
+	}
+
+	fixedTime := time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+		}
+		fixedTime = time.Unix(seconds, 0).UTC()
+	}
+
+	zw.reproducible = true
+	zw.fixedTime = fixedTime
+	return nil
+}
+
+// SetNTFSTimes makes the writer add an NTFS extra field to every entry,
+// so that Windows tools see the times to 100 nanoseconds rather than to
+// the second. It has no effect in reproducible mode.
+func (zw *ZipWriter) SetNTFSTimes(on bool) {
+	zw.ntfsTimes = on
+}
+
+// SetObserver registers o to receive progress reports for every entry
+// written from now on.
+func (zw *ZipWriter) SetObserver(o Observer) {
+	zw.observer = o
+}
+
+// reportProgress forwards a progress report to the observer, if any.
+func (zw *ZipWriter) reportProgress(name string, compressed, uncompressed int64) {
+	if zw.observer != nil {
+		zw.observer.Progress(name, compressed, uncompressed)
+	}
+}
+
 func isValidUTF8(s string) bool {
 	return utf8.ValidString(s)
 }
 
+// timeToMSDos packs t into an MS-DOS time and date, in t's own location.
+// The seven year bits count from 1980, so times before 1980 are stored as
+// its first second and times after 2107 as its last, rather than wrapping
+// into another year.
 func timeToMSDos(t time.Time) (uint16, uint16) {
-	year := t.Year() - 1980
-	if year < 0 {
-		year = 0
+	switch {
+	case t.Year() < 1980:
+		t = time.Date(1980, time.January, 1, 0, 0, 0, 0, t.Location())
+	case t.Year() > 2107:
+		t = time.Date(2107, time.December, 31, 23, 59, 58, 0, t.Location())
 	}
+	year := t.Year() - 1980
 	dosDate := uint16(year<<9 | int(t.Month())<<5 | t.Day())
 	dosTime := uint16(t.Hour()<<11 | t.Minute()<<5 | t.Second()/2)
 	return dosTime, dosDate
 }
 
+// modeToExternalAttrs packs a file mode into the external attributes field
+// the way Info-ZIP does on Unix: the st_mode in the high 16 bits and the
+// MS-DOS directory bit in the low byte.
+func modeToExternalAttrs(mode os.FileMode) uint32 {
+	unixMode := uint32(mode.Perm())
+	if mode.IsDir() {
+		return (0040000|unixMode)<<16 | 0x10
+	}
+	if mode&os.ModeSymlink != 0 {
+		return (0120000 | unixMode) << 16
+	}
+	return (0100000 | unixMode) << 16
+}
+
+func isDirName(name string) bool {
+	return strings.HasSuffix(name, "/")
+}
+
+// AddFile adds a file stamped with the current time and 0644 permissions.
 func (zw *ZipWriter) AddFile(name string, data []byte) error {
-	// Do some validation
+	return zw.AddFileWith(name, data, FileOptions{})
+}
+
+// AddFileWith adds a file using the metadata in opts.
+func (zw *ZipWriter) AddFileWith(name string, data []byte, opts FileOptions) error {
+	return zw.AddFileContext(context.Background(), name, data, opts)
+}
+
+// AddFileContext is like AddFileWith but stops compressing and writing
+// the file once ctx is done. The archive is then incomplete and must be
+// discarded.
+func (zw *ZipWriter) AddFileContext(ctx context.Context, name string, data []byte, opts FileOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if data == nil {
+		return errors.New("data cannot be nil")
+	}
+	if err := checkEntry(name, int64(len(data)), opts); err != nil {
+		return err
+	}
+
+	if zw.reproducible {
+		zw.pending = append(zw.pending, pendingFile{name: name, data: data, opts: zw.reproducibleOptions(name, opts)})
+		return nil
+	}
+
+	if opts.Modified.IsZero() {
+		opts.Modified = time.Now()
+	}
+	if opts.Mode == 0 {
+		opts.Mode = 0644
+		if isDirName(name) {
+			opts.Mode = 0755 | os.ModeDir
+		}
+	}
+
+	return zw.writeFile(ctx, name, data, opts)
+}
+
+// addPendingPath holds back the file at path, of the given size, until the
+// writer is closed, so that reproducible mode does not keep the contents
+// of every file in memory.
+func (zw *ZipWriter) addPendingPath(name, path string, size int64, opts FileOptions) error {
+	if err := checkEntry(name, size, opts); err != nil {
+		return err
+	}
+	zw.pending = append(zw.pending, pendingFile{name: name, path: path, opts: zw.reproducibleOptions(name, opts)})
+	return nil
+}
+
+// reproducibleOptions replaces the host-dependent metadata in opts with
+// the fixed values of reproducible mode.
+func (zw *ZipWriter) reproducibleOptions(name string, opts FileOptions) FileOptions {
+	opts.Modified = zw.fixedTime
+	opts.Mode = 0644
+	if isDirName(name) {
+		opts.Mode = 0755 | os.ModeDir
+	}
+	return opts
+}
+
+// checkEntry rejects entries that cannot be written without ZIP64.
+func checkEntry(name string, size int64, opts FileOptions) error {
 	if name == "" {
 		return errors.New("zip filename is empty")
 	}
 	if len(name) > 65535 {
-		return errors.New("zip filename is too long (max 65535 bytes)")
+		return fmt.Errorf("%w: filename is %d bytes (max 65535)", ErrTooLarge, len(name))
 	}
-	if data == nil {
-		return errors.New("data cannot be nil")
+	if len(opts.LegacyName) > 65535 {
+		return fmt.Errorf("%w: legacy filename is %d bytes (max 65535)", ErrTooLarge, len(opts.LegacyName))
+	}
+	if opts.LegacyName != nil && !isValidUTF8(name) {
+		return fmt.Errorf("zip filename %q has a legacy name but is not valid UTF-8", name)
+	}
+	if size > math.MaxUint32 {
+		return fmt.Errorf("%w: %s is %d bytes and ZIP64 is not supported", ErrTooLarge, name, size)
+	}
+	return nil
+}
+
+// writeFile compresses a file, writes its local header and data, and
+// records it for the central directory.
+func (zw *ZipWriter) writeFile(ctx context.Context, name string, data []byte, opts FileOptions) (err error) {
+	if zw.observer != nil {
+		zw.observer.EntryStart(name, int64(len(data)))
+		defer func() { zw.observer.EntryDone(name, err) }()
 	}
 
 	// 1. Calculate CRC32
 	crc := crc32.ChecksumIEEE(data)
 
-	// 2. Record where we're writing this file
-	headerOffset := zw.offset
+	// 2. Compress the data if asked to
+	compressed := data
+	deflated := false
+	switch opts.Method {
+	case Store:
+	case Deflate:
+		var buf bytes.Buffer
+		fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return err
+		}
+		err = writeChunks(ctx, fw, data, func(n int64) { zw.reportProgress(name, 0, n) })
+		if err != nil {
+			return err
+		}
+		if err := fw.Close(); err != nil {
+			return err
+		}
+		compressed = buf.Bytes()
+		deflated = true
+
+		// Tiny or incompressible files grow when deflated
+		if len(compressed) >= len(data) {
+			opts.Method = Store
+			compressed = data
+		}
+	default:
+		return fmt.Errorf("%w %d for %s", ErrAlgorithm, opts.Method, name)
+	}
+
+	// TODO: Handle more flags eventually
+	flags := uint16(0)
+	headerName := name
+	if opts.LegacyName != nil {
+		headerName = string(opts.LegacyName)
+	} else if isValidUTF8(name) {
+		flags |= 0x0800 // UTF-8 flag
+	}
+
+	modTime, modDate := timeToMSDos(opts.Modified)
+
+	extra := opts.Extra
+	if !zw.reproducible {
+		extra = zw.hostExtra(opts)
+	}
+	if opts.LegacyName != nil && !slices.ContainsFunc(opts.Extra, func(field ExtraField) bool {
+		return field.ID() == UnicodePathID
+	}) {
+		up := &UnicodePath{CRC32: crc32.ChecksumIEEE(opts.LegacyName), Name: name}
+		extra = append([]ExtraField{up}, extra...)
+	}
+	localExtra, err := encodeExtra(extra, true)
+	if err != nil {
+		return err
+	}
+	centralExtra, err := encodeExtra(extra, false)
+	if err != nil {
+		return err
+	}
+
+	record := fileRecord{
+		name:              headerName,
+		versionMadeBy:     0x0314, // Unix, version 2.0
+		versionNeeded:     20,
+		flags:             flags,
+		compressionMethod: opts.Method,
+		modTime:           modTime,
+		modDate:           modDate,
+		crc32:             crc,
+		compressedSize:    uint32(len(compressed)),
+		uncompressedSize:  uint32(len(data)),
+		extraField:        localExtra,
+		centralExtra:      centralExtra,
+		diskNumberStart:   0,
+		internalAttrs:     0,
+		externalAttrs:     modeToExternalAttrs(opts.Mode),
+	}
+
+	// 3. Write the local file header
+	if err := zw.writeLocalHeader(&record); err != nil {
+		return err
+	}
+
+	// 4. Write file data. Uncompressed bytes were already reported while
+	// deflating, even if the result was then stored.
+	err = writeChunks(ctx, zw.w, compressed, func(n int64) {
+		if deflated {
+			zw.reportProgress(name, n, 0)
+		} else {
+			zw.reportProgress(name, n, n)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// 5. Save file record for central directory and update offset
+	zw.files = append(zw.files, record)
+	zw.offset += int64(len(compressed))
+
+	return nil
+}
+
+// hostExtra returns opts.Extra preceded by an extended timestamp holding
+// the exact times in opts, an NTFS field if SetNTFSTimes is on and, if
+// opts.Owner is set, a UID/GID field, unless opts.Extra already has fields
+// with those IDs.
+func (zw *ZipWriter) hostExtra(opts FileOptions) []ExtraField {
+	has := make(map[uint16]bool)
+	for _, field := range opts.Extra {
+		has[field.ID()] = true
+	}
+
+	var extra []ExtraField
+	if !has[ExtendedTimestampID] && !opts.NoTimestamp {
+		extra = append(extra, &ExtendedTimestamp{ModTime: opts.Modified, AccessTime: opts.Accessed, CreateTime: opts.Created})
+	}
+	if zw.ntfsTimes && !has[NTFSID] {
+		extra = append(extra, &NTFSTimes{ModTime: opts.Modified, AccessTime: opts.Accessed, CreateTime: opts.Created})
+	}
+	if opts.Owner != nil && !has[UnixOwnerID] {
+		extra = append(extra, opts.Owner)
+	}
+	return append(extra, opts.Extra...)
+}
+
+// AddRaw copies an entry that is already compressed, such as one read from
+// another archive, without decompressing it. hdr supplies the metadata and
+// data must yield exactly hdr.CompressedSize bytes. The sizes and CRC are
+// written to the local header, so a data descriptor is never needed. The
+// name and comment are written as hdr.RawFilename and hdr.RawComment while
+// hdr.Filename and hdr.Comment still hold what the reader decoded from
+// them; if either has been changed, as when renaming a copied entry, the
+// new Filename and Comment are written instead, as UTF-8.
+func (zw *ZipWriter) AddRaw(hdr *CentralDirectoryHeader, data io.Reader) (err error) {
+	if zw.reproducible {
+		return errors.New("zip raw entries cannot be added in reproducible mode")
+	}
+	if hdr.Filename == "" {
+		return errors.New("zip filename is empty")
+	}
+
+	// Names and comments read from an archive are copied as the original
+	// bytes. Once either is changed both are written as UTF-8, since flag
+	// bit 11 covers the two together.
+	name, comment := string(hdr.RawFilename), string(hdr.RawComment)
+	flags := hdr.Flags &^ 0x0008 // sizes are known up front
+	if hdr.RawFilename == nil || hdr.Filename != hdr.decodedFilename || hdr.Comment != hdr.decodedComment {
+		name, comment = hdr.Filename, hdr.Comment
+		flags &^= 0x0800
+		if isValidUTF8(name) && isValidUTF8(comment) {
+			flags |= 0x0800
+		}
+	}
+
+	record := fileRecord{
+		name:              name,
+		versionMadeBy:     hdr.VersionMadeBy,
+		versionNeeded:     hdr.VersionNeeded,
+		flags:             flags,
+		compressionMethod: hdr.CompressionMethod,
+		modTime:           hdr.LastModTime,
+		modDate:           hdr.LastModDate,
+		crc32:             hdr.CRC32,
+		compressedSize:    hdr.CompressedSize,
+		uncompressedSize:  hdr.UncompressedSize,
+		extraField:        hdr.ExtraField,
+		centralExtra:      hdr.ExtraField,
+		comment:           comment,
+		diskNumberStart:   0,
+		internalAttrs:     hdr.InternalAttributes,
+		externalAttrs:     hdr.ExternalAttributes,
+	}
+
+	if zw.observer != nil {
+		zw.observer.EntryStart(hdr.Filename, int64(hdr.UncompressedSize))
+		defer func() { zw.observer.EntryDone(hdr.Filename, err) }()
+	}
+
+	if err := zw.writeLocalHeader(&record); err != nil {
+		return err
+	}
+
+	var w io.Writer = zw.w
+	if zw.observer != nil {
+		w = &progressWriter{w: zw.w, report: func(n int64) { zw.reportProgress(hdr.Filename, n, 0) }}
+	}
+	n, err := io.Copy(w, io.LimitReader(data, int64(hdr.CompressedSize)))
+	zw.offset += n
+	if err != nil {
+		return err
+	}
+	if n != int64(hdr.CompressedSize) {
+		return fmt.Errorf("short raw data for %s: got %d bytes, expected %d", hdr.Filename, n, hdr.CompressedSize)
+	}
+	// Raw data is never decompressed, so the whole entry counts at once
+	zw.reportProgress(hdr.Filename, 0, int64(hdr.UncompressedSize))
+
+	zw.files = append(zw.files, record)
+	return nil
+}
+
+// Copy copies entry from r into the archive without recompressing it.
+func (zw *ZipWriter) Copy(r *Reader, entry *CentralDirectoryHeader) error {
+	data, err := r.OpenRaw(entry)
+	if err != nil {
+		return err
+	}
+	return zw.AddRaw(entry, data)
+}
+
+// writeLocalHeader writes the local file header for record at the current
+// offset and records that offset in it.
+func (zw *ZipWriter) writeLocalHeader(record *fileRecord) error {
+	if zw.offset > math.MaxUint32 {
+		return fmt.Errorf("%w: %s would start at offset %d and ZIP64 is not supported", ErrTooLarge, record.name, zw.offset)
+	}
+	record.localHeaderOffset = zw.offset
 
-	// 3. Create the local file header
 	// Signature
 	if err := binary.Write(zw.w, binary.LittleEndian, uint32(LocalFileHeaderSignature)); err != nil {
 		return err
 	}
 
 	// Fixed header fields
-	if err := binary.Write(zw.w, binary.LittleEndian, uint16(20)); err != nil { // version needed
+	if err := binary.Write(zw.w, binary.LittleEndian, record.versionNeeded); err != nil {
 		return err
 	}
-
-	// TODO: Handle more flags eventually
-	flags := uint16(0)
-	if isValidUTF8(name) {
-		flags |= 0x0800 // UTF-8 flag
-	}
-	if err := binary.Write(zw.w, binary.LittleEndian, flags); err != nil { // flags (UTF-8)
+	if err := binary.Write(zw.w, binary.LittleEndian, record.flags); err != nil {
 		return err
 	}
-	if err := binary.Write(zw.w, binary.LittleEndian, uint16(0)); err != nil { // compression method
+	if err := binary.Write(zw.w, binary.LittleEndian, record.compressionMethod); err != nil {
 		return err
 	}
 
 	// Time and date
-	modTime, modDate := timeToMSDos(time.Now())
-	if err := binary.Write(zw.w, binary.LittleEndian, modTime); err != nil {
+	if err := binary.Write(zw.w, binary.LittleEndian, record.modTime); err != nil {
 		return err
 	}
-	if err := binary.Write(zw.w, binary.LittleEndian, modDate); err != nil {
+	if err := binary.Write(zw.w, binary.LittleEndian, record.modDate); err != nil {
 		return err
 	}
 
 	// CRC and sizes
-	if err := binary.Write(zw.w, binary.LittleEndian, crc); err != nil {
+	if err := binary.Write(zw.w, binary.LittleEndian, record.crc32); err != nil {
 		return err
 	}
-	if err := binary.Write(zw.w, binary.LittleEndian, uint32(len(data))); err != nil { // compressed size
+	if err := binary.Write(zw.w, binary.LittleEndian, record.compressedSize); err != nil {
 		return err
 	}
-	if err := binary.Write(zw.w, binary.LittleEndian, uint32(len(data))); err != nil { // uncompressed size
+	if err := binary.Write(zw.w, binary.LittleEndian, record.uncompressedSize); err != nil {
 		return err
 	}
 
 	// Name length and extra field length
-	if err := binary.Write(zw.w, binary.LittleEndian, uint16(len(name))); err != nil {
+	if err := binary.Write(zw.w, binary.LittleEndian, uint16(len(record.name))); err != nil {
 		return err
 	}
-	if err := binary.Write(zw.w, binary.LittleEndian, uint16(0)); err != nil { // no extra field
+	if err := binary.Write(zw.w, binary.LittleEndian, uint16(len(record.extraField))); err != nil {
 		return err
 	}
 
-	// 4. Write filename
-	if _, err := zw.w.Write([]byte(name)); err != nil {
+	// Filename and extra field
+	if _, err := zw.w.Write([]byte(record.name)); err != nil {
 		return err
 	}
-
-	// 5. Write file data
-	if _, err := zw.w.Write(data); err != nil {
+	if _, err := zw.w.Write(record.extraField); err != nil {
 		return err
 	}
 
-	// 6. Save file record for central directory
-	record := fileRecord{
-		name:              name,
-		versionMadeBy:     0x0314, // Unix, version 2.0
-		versionNeeded:     20,
-		flags:             0x0800,
-		compressionMethod: 0,
-		modTime:           modTime,
-		modDate:           modDate,
-		crc32:             crc,
-		compressedSize:    uint32(len(data)),
-		uncompressedSize:  uint32(len(data)),
-		diskNumberStart:   0,
-		internalAttrs:     0,
-		externalAttrs:     0x81A40000, // Unix regular file, 644 permissions
-		localHeaderOffset: headerOffset,
-	}
-	zw.files = append(zw.files, record)
-
-	// 7. Update offset
-	zw.offset += 4 + 26 + int64(len(name)) + int64(len(data)) // signature + header + name + data
-
+	zw.offset += 4 + 26 + int64(len(record.name)) + int64(len(record.extraField)) // signature + header + name + extra
 	return nil
 }
 
+// Close writes the central directory and the end of central directory
+// record. It does not close the underlying writer.
 func (zw *ZipWriter) Close() error {
+	return zw.CloseContext(context.Background())
+}
+
+// CloseContext is like Close but gives up once ctx is done, checking
+// between entries. The archive is then incomplete and must be discarded.
+func (zw *ZipWriter) CloseContext(ctx context.Context) error {
+	// Write out files held back by reproducible mode in name order
+	if zw.reproducible {
+		sort.SliceStable(zw.pending, func(i, j int) bool {
+			return zw.pending[i].name < zw.pending[j].name
+		})
+		for _, p := range zw.pending {
+			data := p.data
+			if p.path != "" {
+				var err error
+				if data, err = os.ReadFile(p.path); err != nil {
+					return err
+				}
+				if err := checkEntry(p.name, int64(len(data)), p.opts); err != nil {
+					return err
+				}
+			}
+			if err := zw.writeFile(ctx, p.name, data, p.opts); err != nil {
+				return err
+			}
+		}
+		zw.pending = nil
+	}
+
 	// Remember where central directory starts
 	centralDirOffset := zw.offset
+	if len(zw.files) > math.MaxUint16 {
+		return fmt.Errorf("%w: %d entries and ZIP64 is not supported", ErrTooLarge, len(zw.files))
+	}
 
 	// 1. Write all central directory entries
 	for _, file := range zw.files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		// Write central directory signature
 		if err := binary.Write(zw.w, binary.LittleEndian, uint32(CentralDirectorySignature)); err != nil {
 			return err
@@ -202,10 +681,10 @@ func (zw *ZipWriter) Close() error {
 		if err := binary.Write(zw.w, binary.LittleEndian, uint16(len(file.name))); err != nil {
 			return err
 		}
-		if err := binary.Write(zw.w, binary.LittleEndian, uint16(0)); err != nil { // extra field length
+		if err := binary.Write(zw.w, binary.LittleEndian, uint16(len(file.centralExtra))); err != nil {
 			return err
 		}
-		if err := binary.Write(zw.w, binary.LittleEndian, uint16(0)); err != nil { // comment length
+		if err := binary.Write(zw.w, binary.LittleEndian, uint16(len(file.comment))); err != nil {
 			return err
 		}
 		if err := binary.Write(zw.w, binary.LittleEndian, file.diskNumberStart); err != nil {
@@ -221,17 +700,26 @@ func (zw *ZipWriter) Close() error {
 			return err
 		}
 
-		// Write the filename
+		// Write the filename, extra field and comment
 		if _, err := zw.w.Write([]byte(file.name)); err != nil {
 			return err
 		}
+		if _, err := zw.w.Write(file.centralExtra); err != nil {
+			return err
+		}
+		if _, err := zw.w.Write([]byte(file.comment)); err != nil {
+			return err
+		}
 
 		// Update offset
-		zw.offset += 4 + 42 + int64(len(file.name)) // signature + fixed header + filename
+		zw.offset += 4 + 42 + int64(len(file.name)+len(file.centralExtra)+len(file.comment))
 	}
 
 	// 2. Calculate central directory size
 	centralDirSize := zw.offset - centralDirOffset
+	if zw.offset > math.MaxUint32 {
+		return fmt.Errorf("%w: central directory ends at offset %d and ZIP64 is not supported", ErrTooLarge, zw.offset)
+	}
 
 	// 3. Write End of Central Directory
 	if err := binary.Write(zw.w, binary.LittleEndian, uint32(EndOfCentralDirectorySignature)); err != nil {
@@ -4,6 +4,8 @@ import (
 	"archive/zip"
 	"bytes"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -313,6 +315,27 @@ func TestTimeToMSDos(t *testing.T) {
 	}
 }
 
+func TestTimeToMSDosClamps(t *testing.T) {
+	tests := []struct {
+		in, expected time.Time
+	}{
+		{time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2108, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2107, 12, 31, 23, 59, 58, 0, time.UTC)},
+		{time.Date(2107, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2107, 6, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		dosTime, dosDate := timeToMSDos(tt.in)
+		got, err := msDosToTime(dosDate, dosTime, time.UTC)
+		if err != nil {
+			t.Errorf("%v: msDosToTime failed: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("%v: expected %v, got %v", tt.in, tt.expected, got)
+		}
+	}
+}
+
 func TestEmptyZip(t *testing.T) {
 	var buf bytes.Buffer
 	zw := NewZipWriter(&buf)
@@ -335,6 +358,188 @@ func TestEmptyZip(t *testing.T) {
 	}
 }
 
+func TestSetPrefix(t *testing.T) {
+	stub := []byte("#!/bin/sh\nexec unzip -o \"$0\"\n")
+
+	for _, mode := range []OffsetMode{AbsoluteOffsets, RelativeOffsets} {
+		var buf bytes.Buffer
+		zw := NewZipWriter(&buf)
+
+		if err := zw.SetPrefixBytes(stub, mode); err != nil {
+			t.Fatalf("SetPrefixBytes failed: %v", err)
+		}
+		if err := zw.AddFile("payload.txt", []byte("payload")); err != nil {
+			t.Fatalf("AddFile failed: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		if !bytes.HasPrefix(buf.Bytes(), stub) {
+			t.Fatalf("Output does not start with the prefix")
+		}
+
+		// The standard library reader copes with both layouts
+		zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("Failed to open prefixed ZIP (mode %d): %v", mode, err)
+		}
+		rc, err := zipReader.File[0].Open()
+		if err != nil {
+			t.Fatalf("Failed to open entry (mode %d): %v", mode, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(content) != "payload" {
+			t.Errorf("Content mismatch (mode %d): %q", mode, content)
+		}
+
+		r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("NewReader failed (mode %d): %v", mode, err)
+		}
+		if r.PrefixLength() != int64(len(stub)) {
+			t.Errorf("Expected prefix length %d (mode %d), got %d", len(stub), mode, r.PrefixLength())
+		}
+
+		expectedOffset := uint32(0)
+		if mode == AbsoluteOffsets {
+			expectedOffset = uint32(len(stub))
+		}
+		if r.Files[0].LocalHeaderOffset != expectedOffset {
+			t.Errorf("Expected recorded offset %d (mode %d), got %d",
+				expectedOffset, mode, r.Files[0].LocalHeaderOffset)
+		}
+	}
+}
+
+func TestSetPrefixAfterAddFile(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("a.txt", []byte("a"))
+
+	if err := zw.SetPrefixBytes([]byte("stub"), AbsoluteOffsets); err == nil {
+		t.Error("Expected error when setting a prefix after adding a file")
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	build := func(names []string) []byte {
+		var buf bytes.Buffer
+		zw := NewZipWriter(&buf)
+		if err := zw.SetReproducible(); err != nil {
+			t.Fatalf("SetReproducible failed: %v", err)
+		}
+		for _, name := range names {
+			opts := FileOptions{Modified: time.Now(), Mode: 0600}
+			if err := zw.AddFileWith(name, []byte("content of "+name), opts); err != nil {
+				t.Fatalf("AddFileWith failed: %v", err)
+			}
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	first := build([]string{"b.txt", "a.txt", "dir/c.txt"})
+	time.Sleep(10 * time.Millisecond)
+	second := build([]string{"dir/c.txt", "b.txt", "a.txt"})
+
+	if !bytes.Equal(first, second) {
+		t.Fatal("Reproducible archives differ")
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		t.Fatalf("Failed to open ZIP: %v", err)
+	}
+
+	expectedNames := []string{"a.txt", "b.txt", "dir/c.txt"}
+	expectedTime := time.Unix(1700000000, 0).UTC()
+	for i, file := range zipReader.File {
+		if file.Name != expectedNames[i] {
+			t.Errorf("Expected entry %d to be %s, got %s", i, expectedNames[i], file.Name)
+		}
+		if file.Mode().Perm() != 0644 {
+			t.Errorf("Expected 0644 permissions for %s, got %o", file.Name, file.Mode().Perm())
+		}
+		// DOS times have two-second resolution and no time zone
+		if !file.Modified.Equal(time.Date(expectedTime.Year(), expectedTime.Month(), expectedTime.Day(),
+			expectedTime.Hour(), expectedTime.Minute(), expectedTime.Second()&^1, 0, time.UTC)) {
+			t.Errorf("Unexpected modification time for %s: %v", file.Name, file.Modified)
+		}
+	}
+}
+
+func TestReproducibleAddPathsReadsOnClose(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	if err := os.WriteFile(path, []byte("written before AddPaths"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	if err := zw.SetReproducible(); err != nil {
+		t.Fatalf("SetReproducible failed: %v", err)
+	}
+	if _, err := zw.AddPaths([]string{path}, Deflate); err != nil {
+		t.Fatalf("AddPaths failed: %v", err)
+	}
+	if zw.pending[0].data != nil {
+		t.Error("Expected the file to be held back by path, not by contents")
+	}
+
+	// The contents are only read when the archive is written out
+	if err := os.WriteFile(path, []byte("written before Close"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open ZIP: %v", err)
+	}
+	rc, err := zipReader.File[0].Open()
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(content) != "written before Close" {
+		t.Errorf("Expected the contents at Close, got %q (%v)", content, err)
+	}
+}
+
+func TestAddFileWithMode(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	zw.AddFileWith("bin/tool", []byte("#!/bin/sh\n"), FileOptions{Modified: modified, Mode: 0755})
+	zw.AddFileWith("bin/", []byte{}, FileOptions{Modified: modified})
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Failed to open ZIP: %v", err)
+	}
+
+	if mode := zipReader.File[0].Mode(); mode != 0755 {
+		t.Errorf("Expected mode 0755 for bin/tool, got %v", mode)
+	}
+	if mode := zipReader.File[1].Mode(); !mode.IsDir() || mode.Perm() != 0755 {
+		t.Errorf("Expected directory with 0755 for bin/, got %v", mode)
+	}
+}
+
 // Benchmark tests
 func BenchmarkAddSmallFile(b *testing.B) {
 	content := []byte("Hello, World!")
@@ -3,68 +3,87 @@ package zip
 import (
 	"bytes"
 	"compress/flate"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
 	"os"
 	"strings"
+	"time"
 )
 
-func findEOCD(file *os.File) (int64, error) {
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return 0, err
-	}
-
-	// Get the size of the file
-	fileSize := fileInfo.Size()
-
-	if fileSize < EOCDMinSize {
-		return 0, errors.New("file too small")
+// findEOCD returns the offset of the end of central directory record,
+// searching backwards from the end because the record is followed by a
+// comment of up to 64K.
+func findEOCD(r io.ReaderAt, size int64) (int64, error) {
+	if size < EOCDMinSize {
+		return 0, &FormatError{Record: RecordEOCD, Offset: -1, Field: "file size", Expected: fmt.Sprintf("at least %d", EOCDMinSize), Actual: size}
 	}
 
 	maxCommentSize := int64(65535) // 64K - 1
-	searchStart := fileSize - EOCDMinSize - maxCommentSize
+	searchStart := size - EOCDMinSize - maxCommentSize
 	if searchStart < 0 {
 		searchStart = 0
 	}
 
 	// Read from searchStart to the end of the file
-	bufSize := fileSize - searchStart
-	buf := make([]byte, bufSize)
-
-	_, err = file.Seek(searchStart, 0)
-	if err != nil {
-		return 0, err
+	buf := make([]byte, size-searchStart)
+	if n, err := r.ReadAt(buf, searchStart); n < len(buf) {
+		return 0, truncated(RecordEOCD, searchStart, err)
 	}
 
-	_, err = file.Read(buf)
-	if err != nil {
-		return 0, err
+	// Search the buffer for the EOCD signature. The same four bytes may
+	// turn up inside the comment, so a match only counts if the record
+	// and the comment it declares fit in the file.
+	signature := []byte{0x50, 0x4b, 0x05, 0x06}
+	end := len(buf)
+	for {
+		sigPos := bytes.LastIndex(buf[:end], signature)
+		if sigPos < 0 {
+			return 0, &FormatError{Record: RecordEOCD, Offset: -1, Err: errors.New("signature not found")}
+		}
+		if sigPos+EOCDMinSize <= len(buf) {
+			commentLength := int(binary.LittleEndian.Uint16(buf[sigPos+EOCDMinSize-2:]))
+			if sigPos+EOCDMinSize+commentLength <= len(buf) {
+				// Calculate the position of the EOCD signature in the file
+				return searchStart + int64(sigPos), nil
+			}
+		}
+		end = sigPos
 	}
+}
 
-	// Search the buffer for the EOCD signature
-	signature := []byte{0x50, 0x4b, 0x05, 0x06}
-	sigPos := bytes.LastIndex(buf, signature)
-	if sigPos > 0 {
-		// Calculate the position of the EOCD signature in the file
-		eocdPos := searchStart + int64(sigPos)
-		return eocdPos, nil
-	} else {
-		return 0, errors.New("EOCD signature not found")
+// recordReader returns a reader positioned at offset that reads through
+// to the end of the archive, which is size bytes long. Every read through
+// it is a ReadAt, so concurrent readers never disturb each other, and
+// lengths that run past the end of the archive fail with
+// io.ErrUnexpectedEOF instead of reading beyond it.
+func recordReader(r io.ReaderAt, size, offset int64) *io.SectionReader {
+	return io.NewSectionReader(r, offset, size-offset)
+}
+
+// checkOffset reports a record that would start outside an archive of
+// size bytes.
+func checkOffset(record RecordType, size, offset int64) error {
+	if offset < 0 || offset > size {
+		return &FormatError{Record: record, Offset: offset, Field: "offset",
+			Expected: fmt.Sprintf("between 0 and %d", size), Actual: offset}
 	}
+	return nil
 }
 
-func parseEOCD(file *os.File, offset int64) (*EndOfCentralDirectory, error) {
-	_, err := file.Seek(offset, 0)
-	if err != nil {
+func parseEOCD(r io.ReaderAt, size, offset int64) (_ *EndOfCentralDirectory, err error) {
+	defer func() { err = truncated(RecordEOCD, offset, err) }()
+	if err := checkOffset(RecordEOCD, size, offset); err != nil {
 		return nil, err
 	}
+	file := recordReader(r, size, offset)
 
 	buf := make([]byte, EOCDMinSize)
-	_, err = file.Read(buf)
-	if err != nil {
+	if _, err := io.ReadFull(file, buf); err != nil {
 		return nil, err
 	}
 
@@ -97,8 +116,7 @@ func parseEOCD(file *os.File, offset int64) (*EndOfCentralDirectory, error) {
 
 	if eocd.CommentLength > 0 {
 		commentBuf := make([]byte, eocd.CommentLength)
-		_, err := file.Read(commentBuf)
-		if err != nil {
+		if _, err := io.ReadFull(file, commentBuf); err != nil {
 			return nil, err
 		}
 		eocd.Comment = string(commentBuf)
@@ -107,11 +125,12 @@ func parseEOCD(file *os.File, offset int64) (*EndOfCentralDirectory, error) {
 	return eocd, nil
 }
 
-func readCentralDirectoryEntry(file *os.File, offset int64) (*CentralDirectoryHeader, int64, error) {
-	_, err := file.Seek(offset, 0)
-	if err != nil {
+func readCentralDirectoryEntry(r io.ReaderAt, size, offset int64) (_ *CentralDirectoryHeader, _ int64, err error) {
+	defer func() { err = truncated(RecordCentralDirectory, offset, err) }()
+	if err := checkOffset(RecordCentralDirectory, size, offset); err != nil {
 		return nil, 0, err
 	}
+	file := recordReader(r, size, offset)
 
 	// Read and check signature
 	var signature uint32
@@ -120,7 +139,8 @@ func readCentralDirectoryEntry(file *os.File, offset int64) (*CentralDirectoryHe
 		return nil, 0, err
 	}
 	if signature != CentralDirectorySignature {
-		return nil, 0, fmt.Errorf("invalid signature: %x", signature)
+		return nil, 0, &FormatError{Record: RecordCentralDirectory, Offset: offset, Field: "signature",
+			Expected: uint32(CentralDirectorySignature), Actual: signature}
 	}
 
 	// Read the fixed part of the central directory header
@@ -169,15 +189,16 @@ func readCentralDirectoryEntry(file *os.File, offset int64) (*CentralDirectoryHe
 
 	// Read the filename
 	filenameBuf := make([]byte, cd.FilenameLength)
-	_, err = file.Read(filenameBuf)
+	_, err = io.ReadFull(file, filenameBuf)
 	if err != nil {
 		return nil, 0, err
 	}
 	cd.Filename = string(filenameBuf)
+	cd.RawFilename = filenameBuf
 
 	// Read the extra field
 	extraFieldBuf := make([]byte, cd.ExtraFieldLength)
-	_, err = file.Read(extraFieldBuf)
+	_, err = io.ReadFull(file, extraFieldBuf)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -185,25 +206,34 @@ func readCentralDirectoryEntry(file *os.File, offset int64) (*CentralDirectoryHe
 
 	// Read the comment
 	commentBuf := make([]byte, cd.CommentLength)
-	_, err = file.Read(commentBuf)
+	_, err = io.ReadFull(file, commentBuf)
 	if err != nil {
 		return nil, 0, err
 	}
 	cd.Comment = string(commentBuf)
+	cd.RawComment = commentBuf
+	cd.decodedFilename, cd.decodedComment = cd.Filename, cd.Comment
 
 	// return the entry and the next offset
-	nextOffset := offset + 4 + 42 + int64(cd.FilenameLength+cd.ExtraFieldLength+cd.CommentLength)
+	nextOffset := offset + 4 + 42 + int64(cd.FilenameLength) + int64(cd.ExtraFieldLength) + int64(cd.CommentLength)
 	return cd, nextOffset, nil
 }
 
-func readLocalFileHeader(file *os.File, offset int64) (*LocalFileHeader, int64, error) {
-	file.Seek(offset, 0)
+func readLocalFileHeader(r io.ReaderAt, size, offset int64) (_ *LocalFileHeader, _ int64, err error) {
+	defer func() { err = truncated(RecordLocalHeader, offset, err) }()
+	if err := checkOffset(RecordLocalHeader, size, offset); err != nil {
+		return nil, 0, err
+	}
+	file := recordReader(r, size, offset)
 
 	// Check the signature
 	var signature uint32
-	binary.Read(file, binary.LittleEndian, &signature)
+	if err := binary.Read(file, binary.LittleEndian, &signature); err != nil {
+		return nil, 0, err
+	}
 	if signature != LocalFileHeaderSignature {
-		return nil, 0, fmt.Errorf("invalid local file header signature: %x", signature)
+		return nil, 0, &FormatError{Record: RecordLocalHeader, Offset: offset, Field: "signature",
+			Expected: uint32(LocalFileHeaderSignature), Actual: signature}
 	}
 
 	// Read the fixed part of the local file header
@@ -221,7 +251,7 @@ func readLocalFileHeader(file *os.File, offset int64) (*LocalFileHeader, int64,
 	}
 
 	var fixed fixedPart
-	err := binary.Read(file, binary.LittleEndian, &fixed)
+	err = binary.Read(file, binary.LittleEndian, &fixed)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -241,7 +271,7 @@ func readLocalFileHeader(file *os.File, offset int64) (*LocalFileHeader, int64,
 
 	// Read the filename
 	filenameBuf := make([]byte, lh.FilenameLength)
-	_, err = file.Read(filenameBuf)
+	_, err = io.ReadFull(file, filenameBuf)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -249,121 +279,436 @@ func readLocalFileHeader(file *os.File, offset int64) (*LocalFileHeader, int64,
 
 	// Read the extra field
 	extraFieldBuf := make([]byte, lh.ExtraFieldLength)
-	_, err = file.Read(extraFieldBuf)
+	_, err = io.ReadFull(file, extraFieldBuf)
 	if err != nil {
 		return nil, 0, err
 	}
 	lh.ExtraField = extraFieldBuf
 
-	return lh, offset + 4 + 26 + int64(lh.FilenameLength+lh.ExtraFieldLength), nil
+	return lh, offset + 4 + 26 + int64(lh.FilenameLength) + int64(lh.ExtraFieldLength), nil
+}
+
+// Reader provides access to the central directory of a ZIP archive.
+//
+// All reads go through io.ReaderAt, so a Reader is safe for concurrent
+// use: any number of goroutines may open and decompress entries at once.
+type Reader struct {
+	r            io.ReaderAt
+	size         int64
+	EOCD         *EndOfCentralDirectory
+	Files        []*CentralDirectoryHeader
+	eocdOffset   int64
+	baseOffset   int64
+	prefixLength int64
+}
+
+// ReadCloser is a Reader for an archive opened by OpenReader, which must be
+// closed when no longer needed.
+type ReadCloser struct {
+	*Reader
+	file *os.File
+}
+
+// ReaderOptions controls how an archive is read.
+type ReaderOptions struct {
+	// NameDecoder decodes the names and comments of entries that do not
+	// set the UTF-8 flag, such as those written by older Windows tools.
+	// Defaults to DecodeCP437, as the spec requires. Regional archives may
+	// need another code page, such as Shift-JIS, GBK or CP866, supplied
+	// for example by golang.org/x/text. If it fails, the raw bytes are
+	// used unchanged.
+	NameDecoder func(raw []byte) (string, error)
+	// DetectEncoding chooses the decoder for names and comments from
+	// Encodings by scoring every candidate against all the legacy names in
+	// the central directory, as DetectEncoding does, or falls back to
+	// CP437 if Encodings is empty. It is ignored if NameDecoder is set.
+	DetectEncoding bool
+	// Location is the time zone MS-DOS modification times are taken to be
+	// in. Defaults to time.Local, as the tools that write them use the
+	// local time of the machine they run on.
+	Location *time.Location
 }
 
-func extractFile(file *os.File, centralDir *CentralDirectoryHeader) ([]byte, error) {
-	// Read local header
-	localHeader, dataOffset, err := readLocalFileHeader(file, int64(centralDir.LocalHeaderOffset))
+// OpenReader opens the archive at path.
+func OpenReader(path string) (*ReadCloser, error) {
+	return OpenReaderWithOptions(path, ReaderOptions{})
+}
+
+// OpenReaderWithOptions opens the archive at path as directed by opts.
+func OpenReaderWithOptions(path string, opts ReaderOptions) (*ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := file.Stat()
 	if err != nil {
+		file.Close()
 		return nil, err
 	}
 
-	// Print the local header struct for debugging
-	fmt.Printf("Local File Header for %s:\n", centralDir.Filename)
-	fmt.Printf("  Version Needed: %d\n", localHeader.VersionNeeded)
-	fmt.Printf("  Flags: %04x\n", localHeader.Flags)
-	fmt.Printf("  Compression Method: %d\n", localHeader.CompressionMethod)
-	fmt.Printf("  Last Mod Time: %d\n", localHeader.LastModTime)
-	fmt.Printf("  Last Mod Date: %d\n", localHeader.LastModDate)
-	fmt.Printf("  CRC32: %08x\n", localHeader.CRC32)
-	fmt.Printf("  Compressed Size: %d\n", localHeader.CompressedSize)
-	fmt.Printf("  Uncompressed Size: %d\n", localHeader.UncompressedSize)
-	fmt.Printf("  Filename Length: %d\n", localHeader.FilenameLength)
-	fmt.Printf("  Extra Field Length: %d\n", localHeader.ExtraFieldLength)
-	fmt.Printf("  Filename: %s\n", localHeader.Filename)
-	fmt.Printf("  Extra Field: %x\n", localHeader.ExtraField)
-
-	// Check if the local header's compressed size is zero and if the data descriptor flag is set
-	if localHeader.CompressedSize == 0 && (localHeader.Flags&0x0008) != 0 {
-		// Use sizes from central directory instead
-		localHeader.CompressedSize = centralDir.CompressedSize
-		localHeader.UncompressedSize = centralDir.UncompressedSize
-	}
-
-	// Seek to compressed data
-	file.Seek(dataOffset, 0)
-
-	// Read compressed data
-	compressedData := make([]byte, localHeader.CompressedSize)
-	_, err = file.Read(compressedData)
+	r, err := NewReaderWithOptions(file, stat.Size(), opts)
 	if err != nil {
+		file.Close()
 		return nil, err
 	}
+	return &ReadCloser{Reader: r, file: file}, nil
+}
+
+// Close closes the archive file.
+func (rc *ReadCloser) Close() error {
+	return rc.file.Close()
+}
+
+// NewReader locates the end of central directory record in r, which holds
+// size bytes, and reads every central directory entry.
+//
+// Archives that have data prepended to them (self-extracting executables,
+// shell-script installers, CRX headers) store offsets relative to the start
+// of the zip data rather than the start of the file. The central directory
+// always ends where the EOCD record begins, so the difference between the
+// EOCD position and CentralDirOffset+CentralDirSize is the length of the
+// prepended data. It is added to every offset read from the archive.
+// Archives whose offsets were already adjusted to be absolute (as
+// "zip -A" does) have a base offset of 0 and need no correction.
+func NewReader(ra io.ReaderAt, size int64) (*Reader, error) {
+	return NewReaderWithOptions(ra, size, ReaderOptions{})
+}
 
-	// Decompress
-	if localHeader.CompressionMethod == 8 { // Deflate
-		reader := flate.NewReader(bytes.NewReader(compressedData))
-		defer reader.Close()
+// NewReaderWithOptions is like NewReader but reads the archive as directed
+// by opts.
+func NewReaderWithOptions(ra io.ReaderAt, size int64, opts ReaderOptions) (*Reader, error) {
+	eocdPos, err := findEOCD(ra, size)
+	if err != nil {
+		return nil, err
+	}
 
-		decompressed, err := io.ReadAll(reader)
+	eocd, err := parseEOCD(ra, size, eocdPos)
+	if err != nil {
+		return nil, err
+	}
+
+	baseOffset := eocdPos - (int64(eocd.CentralDirOffset) + int64(eocd.CentralDirSize))
+	if baseOffset < 0 {
+		return nil, &FormatError{Record: RecordEOCD, Offset: eocdPos, Field: "central directory end",
+			Expected: fmt.Sprintf("at most %d", eocdPos), Actual: int64(eocd.CentralDirOffset) + int64(eocd.CentralDirSize)}
+	}
+
+	// Every central directory header takes at least 46 bytes, so a count
+	// that cannot fit is rejected before anything is allocated for it
+	if int64(eocd.TotalEntries)*46 > int64(eocd.CentralDirSize) {
+		return nil, &FormatError{Record: RecordEOCD, Offset: eocdPos, Field: "total entries",
+			Expected: fmt.Sprintf("at most %d", eocd.CentralDirSize/46), Actual: eocd.TotalEntries}
+	}
+
+	r := &Reader{
+		r:          ra,
+		size:       size,
+		EOCD:       eocd,
+		Files:      make([]*CentralDirectoryHeader, 0, eocd.TotalEntries),
+		eocdOffset: eocdPos,
+		baseOffset: baseOffset,
+	}
+
+	offset := baseOffset + int64(eocd.CentralDirOffset)
+	r.prefixLength = offset
+	for i := 0; i < int(eocd.TotalEntries); i++ {
+		entry, nextOffset, err := readCentralDirectoryEntry(ra, size, offset)
 		if err != nil {
 			return nil, err
 		}
+		if nextOffset > eocdPos {
+			return nil, &FormatError{Record: RecordCentralDirectory, Offset: offset, Entry: entry.Filename,
+				Field: "end", Expected: fmt.Sprintf("at most %d", eocdPos), Actual: nextOffset}
+		}
+		r.Files = append(r.Files, entry)
+		r.prefixLength = min(r.prefixLength, r.LocalHeaderOffset(entry))
+		offset = nextOffset
+	}
 
-		// Verify size
-		if len(decompressed) != int(localHeader.UncompressedSize) {
-			return nil, fmt.Errorf("size mismatch: got %d, expected %d",
-				len(decompressed), localHeader.UncompressedSize)
+	// Detection needs every name, so names are decoded once all are read
+	decode := opts.NameDecoder
+	if decode == nil {
+		decode = DecodeCP437
+		if opts.DetectEncoding {
+			// Encodings may have been emptied by the caller
+			if scores := r.DetectEncoding(); len(scores) > 0 {
+				decode = scores[0].Encoding.Decode
+			}
 		}
+	}
+	for _, entry := range r.Files {
+		decodeNames(entry, decode)
+		entry.location = opts.Location
+	}
 
-		return decompressed, nil
+	return r, nil
+}
+
+// PrefixLength returns the number of bytes that precede the first record
+// of the zip data, or 0 for a plain archive. This is the length of the
+// prepended stub whether the archive records relative or absolute offsets.
+func (r *Reader) PrefixLength() int64 {
+	return r.prefixLength
+}
+
+// Prefix returns a reader over the data prepended to the archive, so the
+// stub can be inspected or stripped.
+func (r *Reader) Prefix() io.Reader {
+	return io.NewSectionReader(r.r, 0, r.prefixLength)
+}
+
+// OffsetMode reports whether the archive's offsets count from the start of
+// the file (AbsoluteOffsets) or from the end of the prepended data
+// (RelativeOffsets). Writing Prefix with SetPrefix in this mode keeps the
+// layout of a rewritten archive.
+func (r *Reader) OffsetMode() OffsetMode {
+	if r.baseOffset > 0 {
+		return RelativeOffsets
 	}
+	return AbsoluteOffsets
+}
+
+// LocalHeaderOffset returns the absolute file offset of the local file
+// header for entry, taking any prepended data into account.
+func (r *Reader) LocalHeaderOffset(entry *CentralDirectoryHeader) int64 {
+	return r.baseOffset + int64(entry.LocalHeaderOffset)
+}
 
-	// If stored (method 0), just return as-is
-	return compressedData, nil
+// Size returns the size of the archive in bytes.
+func (r *Reader) Size() int64 {
+	return r.size
 }
 
-func ReadZip(file *os.File) {
-	eocdPos, err := findEOCD(file)
+// OpenRaw returns a reader over the compressed data of entry, without
+// decompressing it. The sizes come from the central directory, so entries
+// written with a data descriptor are handled too.
+func (r *Reader) OpenRaw(entry *CentralDirectoryHeader) (io.Reader, error) {
+	_, dataOffset, err := readLocalFileHeader(r.r, r.size, r.LocalHeaderOffset(entry))
 	if err != nil {
-		panic(err)
+		return nil, withEntry(err, entry)
+	}
+	if dataOffset+int64(entry.CompressedSize) > r.size {
+		return nil, &FormatError{Record: RecordLocalHeader, Offset: r.LocalHeaderOffset(entry), Entry: entry.Filename,
+			Field: "compressed size", Expected: fmt.Sprintf("at most %d", r.size-dataOffset), Actual: int64(entry.CompressedSize)}
 	}
+	return io.NewSectionReader(r.r, dataOffset, int64(entry.CompressedSize)), nil
+}
 
-	eocd, err := parseEOCD(file, eocdPos)
+// Open returns a reader that decompresses the data of entry. The CRC-32
+// and size are verified once the data has been read to the end.
+func (r *Reader) Open(entry *CentralDirectoryHeader) (io.ReadCloser, error) {
+	raw, err := r.OpenRaw(entry)
 	if err != nil {
-		panic(err)
-	}
-
-	// Print the parsed EOCD struct
-	println("End of Central Directory:")
-	println("Disk Number:", eocd.DiskNumber)
-	println("Disk with CD Start:", eocd.DiskWithCDStart)
-	println("Entries on Disk:", eocd.EntriesOnDisk)
-	println("Total Entries:", eocd.TotalEntries)
-	println("Central Directory Size:", eocd.CentralDirSize)
-	println("Central Directory Offset:", eocd.CentralDirOffset)
-	println("Comment Length:", eocd.CommentLength)
-	println("")
-
-	// Read all Central Directory entries
-	offset := int64(eocd.CentralDirOffset)
-	for i := 0; i < int(eocd.TotalEntries); i++ {
-		centralDirectoryEntry, nextOffset, err := readCentralDirectoryEntry(file, offset)
-		if err != nil {
-			panic(err)
+		return nil, err
+	}
+	return decompress(entry, raw, r.LocalHeaderOffset(entry))
+}
+
+// OpenContext is like Open but reads fail with the context's error once
+// ctx is done.
+func (r *Reader) OpenContext(ctx context.Context, entry *CentralDirectoryHeader) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rc, err := r.Open(entry)
+	if err != nil {
+		return nil, err
+	}
+	return &contextReadCloser{contextReader{ctx, rc}, rc}, nil
+}
+
+// decompress wraps the compressed data of entry, whose local header is at
+// offset, in a reader that decompresses it and verifies the CRC-32 and
+// size.
+func decompress(entry *CentralDirectoryHeader, raw io.Reader, offset int64) (io.ReadCloser, error) {
+	var rc io.ReadCloser
+	switch entry.CompressionMethod {
+	case Store:
+		rc = io.NopCloser(raw)
+	case Deflate:
+		rc = flate.NewReader(raw)
+	default:
+		return nil, fmt.Errorf("%w %d for %s", ErrAlgorithm, entry.CompressionMethod, entry.Filename)
+	}
+
+	return &checksumReader{
+		rc:     rc,
+		hash:   crc32.NewIEEE(),
+		entry:  entry,
+		offset: offset,
+	}, nil
+}
+
+// checksumReader verifies the size and CRC-32 of an entry as it is read.
+type checksumReader struct {
+	rc     io.ReadCloser
+	hash   hash.Hash32
+	entry  *CentralDirectoryHeader
+	offset int64 // of the local header, for errors
+	n      int64
+	err    error
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n, err := c.rc.Read(p)
+	c.hash.Write(p[:n])
+	c.n += int64(n)
+
+	if c.n > int64(c.entry.UncompressedSize) {
+		err = c.sizeError(fmt.Sprintf("more than %d", c.entry.UncompressedSize))
+	} else if err == io.EOF {
+		if c.n != int64(c.entry.UncompressedSize) {
+			err = c.sizeError(c.n)
+		} else if c.hash.Sum32() != c.entry.CRC32 {
+			err = fmt.Errorf("%w for %s: got %08x, expected %08x",
+				ErrChecksum, c.entry.Filename, c.hash.Sum32(), c.entry.CRC32)
 		}
+	} else if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = c.sizeError(fmt.Sprintf("%d before the data ended", c.n))
+	} else if isFlateError(err) {
+		err = &FormatError{Record: RecordLocalHeader, Offset: c.offset, Entry: c.entry.Filename, Err: err}
+	}
 
-		if !strings.HasPrefix(centralDirectoryEntry.Filename, "__MACOSX/") {
-			data, err := extractFile(file, centralDirectoryEntry)
-			if err != nil {
-				fmt.Printf("Error extracting file %s: %v\n", centralDirectoryEntry.Filename, err)
-			} else {
-				fmt.Printf("Extracted %s (%d bytes)\n", centralDirectoryEntry.Filename, len(data))
-			}
+	c.err = err
+	return n, err
+}
+
+// sizeError reports that the entry decompressed to actual bytes instead
+// of the size recorded in the central directory.
+func (c *checksumReader) sizeError(actual any) error {
+	return &FormatError{
+		Record:   RecordLocalHeader,
+		Offset:   c.offset,
+		Entry:    c.entry.Filename,
+		Field:    "uncompressed size",
+		Expected: int64(c.entry.UncompressedSize),
+		Actual:   actual,
+	}
+}
+
+// isFlateError reports whether err is flate's complaint about the
+// compressed data rather than a failure to read it.
+func isFlateError(err error) bool {
+	var corrupt flate.CorruptInputError
+	var internal flate.InternalError
+	return errors.As(err, &corrupt) || errors.As(err, &internal)
+}
+
+func (c *checksumReader) Close() error {
+	return c.rc.Close()
+}
+
+// Modified returns the modification time of the entry, from the most
+// precise source available: the NTFS extra field (100 ns), then the
+// extended timestamp extra field (1 s, UTC), then the MS-DOS date and time
+// (2 s), as DOSTime decodes them. It returns the zero time if none is
+// usable.
+func (h *CentralDirectoryHeader) Modified() time.Time {
+	if ntfs, ok := findExtra[*NTFSTimes](h.ExtraField, false); ok && !ntfs.ModTime.IsZero() {
+		return ntfs.ModTime
+	}
+	if ut, ok := findExtra[*ExtendedTimestamp](h.ExtraField, false); ok && !ut.ModTime.IsZero() {
+		return ut.ModTime
+	}
+	modified, err := h.DOSTime()
+	if err != nil {
+		return time.Time{}
+	}
+	return modified
+}
 
-			// Print the bytes of the data file
-			//fmt.Printf("Data: %s\n", string(data))
-		} else {
-			fmt.Printf("Skipping file %s\n", centralDirectoryEntry.Filename)
+// DOSTime decodes the MS-DOS modification date and time of the entry. They
+// carry no time zone, so they are taken to be in the location set by
+// ReaderOptions.Location, or local time. A date or time that cannot exist,
+// such as month 13 or second 62, is reported as a *FormatError.
+func (h *CentralDirectoryHeader) DOSTime() (time.Time, error) {
+	loc := h.location
+	if loc == nil {
+		loc = time.Local
+	}
+	modified, err := msDosToTime(h.LastModDate, h.LastModTime, loc)
+	if err != nil {
+		return time.Time{}, withEntry(err, h)
+	}
+	return modified, nil
+}
+
+// msDosToTime decodes an MS-DOS date and time in loc. The fields are
+// checked rather than left to time.Date, which would normalise month 13
+// into January of the following year.
+func msDosToTime(dosDate, dosTime uint16, loc *time.Location) (time.Time, error) {
+	year := int(dosDate>>9) + 1980
+	month := time.Month(dosDate >> 5 & 0x0f)
+	day := int(dosDate & 0x1f)
+	hour := int(dosTime >> 11)
+	minute := int(dosTime >> 5 & 0x3f)
+	second := int(dosTime&0x1f) * 2
+
+	invalidDate := func(part string, value int) error {
+		return &FormatError{Record: RecordCentralDirectory, Offset: -1, Field: "last mod date",
+			Expected: "a valid MS-DOS date", Actual: fmt.Sprintf("%s %d", part, value)}
+	}
+	invalidTime := func(part string, value int) error {
+		return &FormatError{Record: RecordCentralDirectory, Offset: -1, Field: "last mod time",
+			Expected: "a valid MS-DOS time", Actual: fmt.Sprintf("%s %d", part, value)}
+	}
+	switch {
+	case month < time.January || month > time.December:
+		return time.Time{}, invalidDate("month", int(month))
+	case day < 1 || day > time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day():
+		return time.Time{}, invalidDate("day", day)
+	case hour > 23:
+		return time.Time{}, invalidTime("hour", hour)
+	case minute > 59:
+		return time.Time{}, invalidTime("minute", minute)
+	case second > 59:
+		return time.Time{}, invalidTime("second", second)
+	}
+	return time.Date(year, month, day, hour, minute, second, 0, loc), nil
+}
+
+// Mode returns the permission and type bits of the entry. Unix hosts store
+// st_mode in the high 16 bits of the external attributes. For archives
+// from other hosts only the MS-DOS directory and read-only bits are used.
+func (h *CentralDirectoryHeader) Mode() os.FileMode {
+	var mode os.FileMode
+
+	hostOS := h.VersionMadeBy >> 8
+	unixMode := h.ExternalAttributes >> 16
+	if (hostOS == 3 || hostOS == 19) && unixMode != 0 { // Unix, OS X
+		mode = os.FileMode(unixMode & 0777)
+		switch unixMode & 0170000 {
+		case 0040000:
+			mode |= os.ModeDir
+		case 0120000:
+			mode |= os.ModeSymlink
+		}
+		if unixMode&04000 != 0 {
+			mode |= os.ModeSetuid
 		}
+		if unixMode&02000 != 0 {
+			mode |= os.ModeSetgid
+		}
+		if unixMode&01000 != 0 {
+			mode |= os.ModeSticky
+		}
+	} else {
+		mode = 0644
+		if h.ExternalAttributes&0x01 != 0 { // read-only
+			mode = 0444
+		}
+		if h.ExternalAttributes&0x10 != 0 {
+			mode = 0755 | os.ModeDir
+		}
+	}
 
-		offset = nextOffset
+	if strings.HasSuffix(h.Filename, "/") {
+		mode |= os.ModeDir
 	}
+	return mode
 }
@@ -0,0 +1,396 @@
+package zip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeTempFile writes data to a file in a temporary directory and returns
+// its path.
+func writeTempFile(t *testing.T, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "archive.zip")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write temp file: %v", err)
+	}
+	return path
+}
+
+func TestNewReader(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("hello.txt", []byte("Hello, World!"))
+	zw.AddFile("readme.txt", []byte("This is a readme file."))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := OpenReader(writeTempFile(t, buf.Bytes()))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer r.Close()
+
+	if r.PrefixLength() != 0 {
+		t.Errorf("Expected no prefix, got %d bytes", r.PrefixLength())
+	}
+	if len(r.Files) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(r.Files))
+	}
+	if r.Files[0].Filename != "hello.txt" || r.Files[1].Filename != "readme.txt" {
+		t.Errorf("Unexpected entries: %s, %s", r.Files[0].Filename, r.Files[1].Filename)
+	}
+}
+
+func TestReaderPrefixedArchive(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("payload.txt", []byte("installer payload"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	stub := []byte("#!/bin/sh\necho installing\nexit 0\n")
+	data := append(append([]byte{}, stub...), buf.Bytes()...)
+	file := bytes.NewReader(data)
+
+	r, err := NewReader(file, int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	if r.PrefixLength() != int64(len(stub)) {
+		t.Errorf("Expected prefix length %d, got %d", len(stub), r.PrefixLength())
+	}
+
+	prefix, err := io.ReadAll(r.Prefix())
+	if err != nil {
+		t.Fatalf("Failed to read prefix: %v", err)
+	}
+	if !bytes.Equal(prefix, stub) {
+		t.Errorf("Prefix mismatch. Expected %q, got %q", stub, prefix)
+	}
+
+	entry := r.Files[0]
+	if r.LocalHeaderOffset(entry) != int64(len(stub)) {
+		t.Errorf("Expected local header at %d, got %d", len(stub), r.LocalHeaderOffset(entry))
+	}
+
+	lh, _, err := readLocalFileHeader(file, int64(len(data)), r.LocalHeaderOffset(entry))
+	if err != nil {
+		t.Fatalf("Failed to read local header: %v", err)
+	}
+	if lh.Filename != "payload.txt" {
+		t.Errorf("Expected payload.txt, got %s", lh.Filename)
+	}
+}
+
+func TestReaderOffsetMode(t *testing.T) {
+	stub := []byte("#!/bin/sh\nexit 0\n")
+	for _, mode := range []OffsetMode{AbsoluteOffsets, RelativeOffsets} {
+		var buf bytes.Buffer
+		zw := NewZipWriter(&buf)
+		if err := zw.SetPrefixBytes(stub, mode); err != nil {
+			t.Fatalf("SetPrefixBytes failed: %v", err)
+		}
+		zw.AddFile("payload.txt", []byte("payload"))
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		if r.OffsetMode() != mode {
+			t.Errorf("Expected offset mode %d, got %d", mode, r.OffsetMode())
+		}
+
+		// Rewriting with the same prefix and mode gives the same bytes
+		var rewritten bytes.Buffer
+		zw = NewZipWriter(&rewritten)
+		if err := zw.SetPrefix(r.Prefix(), r.OffsetMode()); err != nil {
+			t.Fatalf("SetPrefix failed: %v", err)
+		}
+		if err := zw.Copy(r, r.Files[0]); err != nil {
+			t.Fatalf("Copy failed: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if !bytes.Equal(rewritten.Bytes(), buf.Bytes()) {
+			t.Errorf("Offset mode %d: rewritten archive differs from the original", mode)
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("a.txt", []byte("a"))
+	zw.Close()
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	if r.PrefixLength() != 0 || r.OffsetMode() != AbsoluteOffsets {
+		t.Errorf("Expected a plain archive to have no prefix and absolute offsets")
+	}
+}
+
+// zip64Archive returns an archive with one entry and ZIP64 end of central
+// directory records whose size field is recordSize. The EOCD's central
+// directory size takes in the ZIP64 records, so no prefix is inferred.
+func zip64Archive(t testing.TB, recordSize uint64) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("a.txt", []byte("a"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	data := buf.Bytes()
+	eocdPos := len(data) - EOCDMinSize
+	eocd := append([]byte{}, data[eocdPos:]...)
+	cdSize := binary.LittleEndian.Uint32(eocd[12:])
+	cdOffset := binary.LittleEndian.Uint32(eocd[16:])
+
+	z64 := binary.LittleEndian.AppendUint32(nil, Zip64EndOfCentralDirectorySignature)
+	z64 = binary.LittleEndian.AppendUint64(z64, recordSize)
+	z64 = binary.LittleEndian.AppendUint16(z64, 45)
+	z64 = binary.LittleEndian.AppendUint16(z64, 45)
+	z64 = binary.LittleEndian.AppendUint32(z64, 0)
+	z64 = binary.LittleEndian.AppendUint32(z64, 0)
+	z64 = binary.LittleEndian.AppendUint64(z64, 1)
+	z64 = binary.LittleEndian.AppendUint64(z64, 1)
+	z64 = binary.LittleEndian.AppendUint64(z64, uint64(cdSize))
+	z64 = binary.LittleEndian.AppendUint64(z64, uint64(cdOffset))
+
+	locator := binary.LittleEndian.AppendUint32(nil, Zip64EndOfCentralDirectoryLocatorSignature)
+	locator = binary.LittleEndian.AppendUint32(locator, 0)
+	locator = binary.LittleEndian.AppendUint64(locator, uint64(eocdPos))
+	locator = binary.LittleEndian.AppendUint32(locator, 1)
+
+	binary.LittleEndian.PutUint32(eocd[12:], cdSize+uint32(len(z64)+len(locator)))
+	return slices.Concat(data[:eocdPos], z64, locator, eocd)
+}
+
+func TestZip64Records(t *testing.T) {
+	data := zip64Archive(t, 44)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+	records, err := r.Records()
+	if err != nil {
+		t.Fatalf("Records failed: %v", err)
+	}
+	var found int
+	for _, record := range records {
+		switch record.Type {
+		case RecordZip64EOCD:
+			found++
+			if record.Size != 56 {
+				t.Errorf("Expected a 56-byte ZIP64 EOCD, got %d", record.Size)
+			}
+		case RecordZip64Locator:
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("Expected the ZIP64 EOCD and locator, found %d records", found)
+	}
+
+	// A size that would overflow or run into the locator is rejected
+	for _, size := range []uint64{1 << 63, 45, 10} {
+		data := zip64Archive(t, size)
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("NewReader failed: %v", err)
+		}
+		records, err := r.Records()
+		var fe *FormatError
+		if !errors.As(err, &fe) || fe.Field != "record size" {
+			t.Errorf("Record size %d: expected a record size error, got %v", size, err)
+		}
+		for _, record := range records {
+			if record.Size < 0 {
+				t.Errorf("Record size %d: %v has negative size %d", size, record.Type, record.Size)
+			}
+		}
+	}
+}
+
+func TestRecords(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	zw.AddFile("a.txt", []byte("first"))
+	zw.AddFile("b.txt", []byte("second"))
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("NewReader failed: %v", err)
+	}
+
+	records, err := r.Records()
+	if err != nil {
+		t.Fatalf("Records failed: %v", err)
+	}
+
+	expected := []RecordType{
+		RecordLocalHeader, RecordLocalHeader,
+		RecordCentralDirectory, RecordCentralDirectory,
+		RecordEOCD,
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), len(records))
+	}
+
+	// Every byte of a freshly written archive belongs to a record or to
+	// the file data that follows a local header
+	var end int64
+	for i, record := range records {
+		if record.Type != expected[i] {
+			t.Errorf("Record %d: expected %s, got %s", i, expected[i], record.Type)
+		}
+		if record.Offset != end {
+			t.Errorf("Record %d: expected offset %d, got %d", i, end, record.Offset)
+		}
+		end = record.Offset + record.Size
+		if record.Type == RecordLocalHeader {
+			end += int64(record.Entry.CompressedSize)
+		}
+	}
+	if end != int64(buf.Len()) {
+		t.Errorf("Records end at %d, archive is %d bytes", end, buf.Len())
+	}
+}
+
+func TestReaderConcurrentOpen(t *testing.T) {
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	contents := make(map[string][]byte)
+	for i := 0; i < 16; i++ {
+		name := fmt.Sprintf("file%02d.txt", i)
+		contents[name] = bytes.Repeat([]byte(name), 1000+i)
+		zw.AddFileWith(name, contents[name], FileOptions{Method: Deflate})
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// A single *os.File shared by every goroutine
+	rc, err := OpenReader(writeTempFile(t, buf.Bytes()))
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer rc.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(rc.Files)*4)
+	for round := 0; round < 4; round++ {
+		for _, entry := range rc.Files {
+			wg.Add(1)
+			go func(entry *CentralDirectoryHeader) {
+				defer wg.Done()
+				f, err := rc.Open(entry)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer f.Close()
+				data, err := io.ReadAll(f)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(data, contents[entry.Filename]) {
+					errs <- fmt.Errorf("content mismatch for %s", entry.Filename)
+				}
+			}(entry)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestReaderLocation(t *testing.T) {
+	// Reproducible archives carry only the DOS time
+	t.Setenv("SOURCE_DATE_EPOCH", "1714979290") // 2024-05-06 07:08:10 UTC
+	var buf bytes.Buffer
+	zw := NewZipWriter(&buf)
+	if err := zw.SetReproducible(); err != nil {
+		t.Fatalf("SetReproducible failed: %v", err)
+	}
+	if err := zw.AddFile("a.txt", []byte("a")); err != nil {
+		t.Fatalf("AddFile failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	r, err := NewReaderWithOptions(bytes.NewReader(buf.Bytes()), int64(buf.Len()), ReaderOptions{Location: tokyo})
+	if err != nil {
+		t.Fatalf("NewReaderWithOptions failed: %v", err)
+	}
+	expected := time.Date(2024, 5, 6, 7, 8, 10, 0, tokyo)
+	if got := r.Files[0].Modified(); !got.Equal(expected) || got.Location() != tokyo {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestDOSTimeValidation(t *testing.T) {
+	date := func(year, month, day int) uint16 { return uint16((year-1980)<<9 | month<<5 | day) }
+	clock := func(hour, minute, second int) uint16 { return uint16(hour<<11 | minute<<5 | second/2) }
+
+	tests := []struct {
+		name       string
+		date, time uint16
+		field      string
+	}{
+		{"month 13", date(2020, 13, 1), clock(12, 0, 0), "last mod date"},
+		{"month 0", date(2020, 0, 1), clock(12, 0, 0), "last mod date"},
+		{"day 0", date(2020, 1, 0), clock(12, 0, 0), "last mod date"},
+		{"February 30", date(2020, 2, 30), clock(12, 0, 0), "last mod date"},
+		{"hour 24", date(2020, 1, 1), clock(24, 0, 0), "last mod time"},
+		{"minute 60", date(2020, 1, 1), clock(12, 60, 0), "last mod time"},
+		{"second 62", date(2020, 1, 1), clock(12, 0, 62), "last mod time"},
+	}
+	for _, tt := range tests {
+		entry := &CentralDirectoryHeader{Filename: "a.txt", LastModDate: tt.date, LastModTime: tt.time}
+		_, err := entry.DOSTime()
+		var fe *FormatError
+		if !errors.As(err, &fe) || fe.Field != tt.field || fe.Entry != "a.txt" {
+			t.Errorf("%s: expected a FormatError for %s, got %v", tt.name, tt.field, err)
+		}
+		if !entry.Modified().IsZero() {
+			t.Errorf("%s: expected a zero Modified, got %v", tt.name, entry.Modified())
+		}
+	}
+
+	// The last day of a leap February and second 58 are fine
+	entry := &CentralDirectoryHeader{LastModDate: date(2024, 2, 29), LastModTime: clock(23, 59, 58)}
+	got, err := entry.DOSTime()
+	if err != nil {
+		t.Fatalf("DOSTime failed: %v", err)
+	}
+	if expected := time.Date(2024, 2, 29, 23, 59, 58, 0, time.Local); !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}